@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	pkgcmd "github.com/rancherlabs/corral/pkg/cmd"
+	"github.com/rancherlabs/corral/pkg/config"
+	"github.com/rancherlabs/corral/pkg/corral"
+	_package "github.com/rancherlabs/corral/pkg/package"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const pruneDescription = `
+Remove cached packages and layers that are not used by an existing corral.  Without any flags every unused entry is
+removed.
+
+Examples:
+corral cache prune --older-than 720h
+corral cache prune --max-size 5Gi
+`
+
+func NewCommandCache() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the local package cache.",
+		Long:  "Manage the local package cache.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := cmd.Usage(); err != nil {
+				logrus.Fatalln(err)
+			}
+		},
+	}
+
+	cmd.AddCommand(
+		newCommandList(),
+		newCommandPrune(),
+		newCommandClear())
+
+	return cmd
+}
+
+func newCommandList() *cobra.Command {
+	output := pkgcmd.OutputFormatTable
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List cached packages and layers.",
+		Long:  "List cached packages and layers.  Entries used by an existing corral are marked as in use.",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cache, err := _package.ListCache(corralSources())
+			if err != nil {
+				return err
+			}
+
+			var rows []table.Row
+			for _, e := range cache.Packages {
+				rows = append(rows, entryRow("package", e))
+			}
+			for _, e := range cache.Layers {
+				rows = append(rows, entryRow("layer", e))
+			}
+
+			out, err := pkgcmd.OutputRows(cache, table.Row{"TYPE", "REFERENCE", "TAGS", "DIGEST", "SIZE", "LAST USED", "IN USE"}, rows, output)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(out)
+			return nil
+		},
+	}
+
+	cmd.Flags().VarP(&output, "output", "o", "Output format. One of: table|json|yaml")
+
+	return cmd
+}
+
+func newCommandPrune() *cobra.Command {
+	var olderThan time.Duration
+	var maxSize string
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove cached packages and layers that are not in use.",
+		Long:  pruneDescription,
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			opts := _package.PruneOptions{OlderThan: olderThan}
+
+			if maxSize != "" {
+				q, err := resource.ParseQuantity(maxSize)
+				if err != nil {
+					return fmt.Errorf("invalid max size: %w", err)
+				}
+
+				opts.MaxSize = q.Value()
+			}
+
+			removed, err := _package.PruneCache(corralSources(), opts)
+			if err != nil {
+				return err
+			}
+
+			var freed int64
+			for _, e := range removed {
+				freed += e.Size
+			}
+
+			logrus.Infof("removed %d cache entries, freeing %s", len(removed), formatSize(freed))
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&olderThan, "older-than", 0, "Remove entries that have not been used within the given duration.")
+	cmd.Flags().StringVar(&maxSize, "max-size", "", "Remove the least recently used entries until the cache is smaller than the given size, e.g. 5Gi.")
+
+	return cmd
+}
+
+func newCommandClear() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove every cached package and layer.",
+		Long:  "Remove every cached package and layer.  Packages used by existing corrals are only removed with --force.",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cache, err := _package.ListCache(corralSources())
+			if err != nil {
+				return err
+			}
+
+			for _, e := range cache.Packages {
+				if e.Referenced && !force {
+					return fmt.Errorf("package %s@%s is used by an existing corral, use --force to remove it anyway", e.Reference, e.Digest)
+				}
+			}
+
+			if err = _package.ClearCache(); err != nil {
+				return err
+			}
+
+			logrus.Infof("removed %d cache entries, freeing %s", len(cache.Packages)+len(cache.Layers), formatSize(cache.Size()))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Remove packages even if they are used by existing corrals.")
+
+	return cmd
+}
+
+// corralSources returns the package sources of every corral on this system.
+func corralSources() []string {
+	entries, _ := os.ReadDir(config.CorralRoot("corrals"))
+
+	var sources []string
+	for _, entry := range entries {
+		c, err := corral.Load(config.CorralRoot("corrals", entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		sources = append(sources, c.Source)
+	}
+
+	return sources
+}
+
+func entryRow(kind string, e _package.CacheEntry) table.Row {
+	inUse := ""
+	if e.Referenced {
+		inUse = "yes"
+	}
+
+	return table.Row{kind, e.Reference, strings.Join(e.Tags, ","), e.Digest, formatSize(e.Size), e.LastUsed.Format(time.RFC3339), inUse}
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f%ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
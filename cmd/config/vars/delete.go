@@ -21,8 +21,12 @@ func NewCommandDelete() *cobra.Command {
 func deleteVar(_ *cobra.Command, args []string) {
 	cfg := config.MustLoad()
 
-	for _, arg := range args {
-		delete(cfg.Vars, arg)
+	if profile != "" {
+		DeleteProfileVars(&cfg, profile, args...)
+	} else {
+		for _, arg := range args {
+			delete(cfg.Vars, arg)
+		}
 	}
 
 	err := cfg.Save()
@@ -30,3 +34,19 @@ func deleteVar(_ *cobra.Command, args []string) {
 		logrus.Fatalf("%e", err)
 	}
 }
+
+// DeleteProfileVars removes the variables from the given profile, empty profiles are removed.
+func DeleteProfileVars(cfg *config.Config, profile string, keys ...string) {
+	vs, ok := cfg.Profiles[profile]
+	if !ok {
+		return
+	}
+
+	for _, k := range keys {
+		delete(vs, k)
+	}
+
+	if len(vs) == 0 {
+		delete(cfg.Profiles, profile)
+	}
+}
@@ -0,0 +1,22 @@
+package vars_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rancherlabs/corral/cmd/config/vars"
+	"github.com/rancherlabs/corral/pkg/config"
+)
+
+var _ = Describe("Delete profile", func() {
+	When("the last variable is deleted", func() {
+		It("removes the profile", func() {
+			cfg := &config.Config{Profiles: map[string]map[string]any{
+				"aws-us-east": {"aws_region": "us-east-1"},
+				"aws-us-west": {"aws_region": "us-west-2", "node_count": 3.},
+			}}
+			vars.DeleteProfileVars(cfg, "aws-us-east", "aws_region")
+			vars.DeleteProfileVars(cfg, "aws-us-west", "node_count")
+			Expect(cfg.Profiles).To(Equal(map[string]map[string]any{"aws-us-west": {"aws_region": "us-west-2"}}))
+		})
+	})
+})
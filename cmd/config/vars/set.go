@@ -14,7 +14,13 @@ func NewCommandSet() *cobra.Command {
 		Args:  cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg := config.MustLoad()
-			err := CreateVar(&cfg, args[0], args[1])
+
+			var err error
+			if profile != "" {
+				err = CreateProfileVar(&cfg, profile, args[0], args[1])
+			} else {
+				err = CreateVar(&cfg, args[0], args[1])
+			}
 			if err != nil {
 				return err
 			}
@@ -34,3 +40,21 @@ func CreateVar(cfg *config.Config, key, value string) error {
 	cfg.Vars[key] = v
 	return nil
 }
+
+// CreateProfileVar sets the variable in the given profile, the profile is created if it does not exist.
+func CreateProfileVar(cfg *config.Config, profile, key, value string) error {
+	v, err := vars.FromJson(value)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]map[string]any{}
+	}
+	if cfg.Profiles[profile] == nil {
+		cfg.Profiles[profile] = map[string]any{}
+	}
+
+	cfg.Profiles[profile][key] = v
+	return nil
+}
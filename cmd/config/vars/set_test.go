@@ -49,3 +49,23 @@ var _ = Describe("Set", func() {
 		})
 	})
 })
+
+var _ = Describe("Set profile", func() {
+	When("the profile does not exist", func() {
+		It("creates the profile", func() {
+			cfg := &config.Config{Vars: map[string]any{}}
+			err := vars.CreateProfileVar(cfg, "aws-us-east", "aws_region", "us-east-1")
+			Expect(err).To(BeNil())
+			Expect(cfg.Profiles["aws-us-east"]).To(Equal(map[string]any{"aws_region": "us-east-1"}))
+			Expect(cfg.Vars).To(BeEmpty())
+		})
+	})
+	When("the profile exists", func() {
+		It("keeps the other variables", func() {
+			cfg := &config.Config{Profiles: map[string]map[string]any{"aws-us-east": {"aws_region": "us-east-1"}}}
+			err := vars.CreateProfileVar(cfg, "aws-us-east", "node_count", "3")
+			Expect(err).To(BeNil())
+			Expect(cfg.Profiles["aws-us-east"]).To(Equal(map[string]any{"aws_region": "us-east-1", "node_count": 3.}))
+		})
+	})
+})
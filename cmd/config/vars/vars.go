@@ -8,7 +8,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var output = pkgcmd.OutputFormatTable
+var (
+	output  = pkgcmd.OutputFormatTable
+	profile string
+)
 
 func NewVarsCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -17,6 +20,14 @@ func NewVarsCommand() *cobra.Command {
 		Long:  "List and modify global configuration.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg := config.MustLoad()
+			if profile != "" {
+				vs, ok := cfg.Profiles[profile]
+				if !ok {
+					return fmt.Errorf("profile [%s] does not exist", profile)
+				}
+				cfg.Vars = vs
+			}
+
 			out, err := ListVars(cfg, output, args...)
 			if err != nil {
 				return err
@@ -26,6 +37,7 @@ func NewVarsCommand() *cobra.Command {
 		},
 	}
 
+	cmd.PersistentFlags().StringVar(&profile, "profile", "", "List or modify the variables of the given profile instead of the global variables.")
 	cmd.Flags().VarP(&output, "output", "o", "Output format. One of: table|json|yaml")
 
 	cmd.AddCommand(
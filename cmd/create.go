@@ -8,6 +8,7 @@ import (
 	"encoding/pem"
 	"os"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 
@@ -30,12 +31,30 @@ var (
 )
 
 const createDescription = `
-Create a new corral from the given package. Packages can either be a valid OCI reference or a path to a local directory.
+Create a new corral from the given package. Packages can be a valid OCI reference, a path to a local directory or
+tarball, a git repository or a tarball URL.
 
 Examples:
 corral create k3s ghcr.io/rancher/k3s
 corral create k3s-ha -v controlplane_count=3 ghcr.io/rancher/k3s
 corral create k3s-custom /home/rancher/issue-1234
+corral create k3s-pr git::https://github.com/rancher/packages.git//k3s?ref=my-branch
+corral create k3s --lock k3s.lock.yaml ghcr.io/rancher/k3s
+corral create k3s-copy --from-lock k3s.lock.yaml
+corral create k3s --offline ghcr.io/rancher/k3s:v1.24
+corral create k3s --profile aws-us-east --var-file k3s.yaml ghcr.io/rancher/k3s
+CORRAL_VAR_controlplane_count=3 corral create k3s-ha ghcr.io/rancher/k3s
+
+Variables are merged in the following order, later sources override earlier ones:
+  1. package defaults
+  2. global variables (corral config vars)
+  3. the profile's variables (corral config vars --profile)
+  4. the lock file's variables (--from-lock)
+  5. variable files (--var-file) in the order they are given
+  6. CORRAL_VAR_<name> environment variables
+  7. variable flags (-v)
+
+On a terminal corral prompts for required variables that are still missing unless no-input is set.
 `
 const ed25519KeyType = "ed25519"
 
@@ -49,7 +68,7 @@ func NewCommandCreate() *cobra.Command {
 		PreRun: func(cmd *cobra.Command, _ []string) {
 			cfgFile := cmd.Flags().Lookup("config").Value.String()
 			if cfgFile != "" {
-				cfgViper.AddConfigPath(cfgFile)
+				cfgViper.SetConfigFile(cfgFile)
 				err := cfgViper.ReadInConfig()
 				if err != nil {
 					logrus.Fatalf("Error reading config file: %v", err)
@@ -63,6 +82,18 @@ func NewCommandCreate() *cobra.Command {
 	cmd.Flags().StringArrayP("variable", "v", []string{}, "Set a variable to configure the package.")
 	_ = cfgViper.BindPFlag("variable", cmd.Flags().Lookup("variable"))
 
+	cmd.Flags().StringArray("var-file", []string{}, "Load variables from a yaml or json file. Later files override earlier files.")
+	_ = cfgViper.BindPFlag("var-file", cmd.Flags().Lookup("var-file"))
+
+	cmd.Flags().String("profile", "", "Apply the variables of the given profile from the global configuration.")
+	_ = cfgViper.BindPFlag("profile", cmd.Flags().Lookup("profile"))
+
+	cmd.Flags().Bool("no-input", false, "Do not prompt for missing required variables.")
+	_ = cfgViper.BindPFlag("no-input", cmd.Flags().Lookup("no-input"))
+
+	cmd.Flags().Bool("save-answers", false, "Save the variables entered at prompts to the global variables. Sensitive variables are not saved.")
+	_ = cfgViper.BindPFlag("save-answers", cmd.Flags().Lookup("save-answers"))
+
 	cmd.Flags().StringP("package", "p", "", "Set a variable to configure the package.")
 	_ = cfgViper.BindPFlag("package", cmd.Flags().Lookup("package"))
 
@@ -72,6 +103,15 @@ func NewCommandCreate() *cobra.Command {
 	cmd.Flags().Bool("skip-cleanup", false, "Do not run terraform destroy when an error is encountered. This can result in un-tracked infrastructure resources!")
 	_ = cfgViper.BindPFlag("skip-cleanup", cmd.Flags().Lookup("skip-cleanup"))
 
+	cmd.Flags().String("lock", "", "Write the pinned package, terraform version and variables to the given file.")
+	_ = cfgViper.BindPFlag("lock", cmd.Flags().Lookup("lock"))
+
+	cmd.Flags().String("from-lock", "", "Create the corral from the package, terraform version and variables in the given lock file.")
+	_ = cfgViper.BindPFlag("from-lock", cmd.Flags().Lookup("from-lock"))
+
+	cmd.Flags().Bool("offline", false, "Load the package from the package cache without contacting the registry.")
+	_ = cfgViper.BindPFlag("offline", cmd.Flags().Lookup("offline"))
+
 	return cmd
 }
 
@@ -85,6 +125,20 @@ func create(cmd *cobra.Command, args []string) error {
 	corr.NodePools = map[string][]corral.Node{}
 	corr.Vars = map[string]any{}
 
+	var lock corral.Lock
+	if lockPath := cfgViper.GetString("from-lock"); lockPath != "" {
+		var err error
+		lock, err = corral.LoadLock(lockPath)
+		if err != nil {
+			logrus.Fatalf("failed to load lock file: %s", err)
+		}
+
+		if corr.Source == "" {
+			corr.Source = lock.Package
+		}
+		corr.TerraformVersion = lock.TerraformVersion
+	}
+
 	if len(args) > 1 {
 		corr.Source = args[1]
 	}
@@ -103,7 +157,30 @@ func create(cmd *cobra.Command, args []string) error {
 		logrus.Fatalf("corral [%s] already exists", corr.Name)
 	}
 
-	// load cli variables
+	// resolve the variables, package defaults are added once the variables are validated
+	var resolver vars.Resolver
+	resolver.Add(vars.OriginGlobal, "", cfg.Vars)
+	if name := cfgViper.GetString("profile"); name != "" {
+		profile, ok := cfg.Profiles[name]
+		if !ok {
+			logrus.Fatalf("profile [%s] does not exist", name)
+		}
+		resolver.Add(vars.OriginProfile, name, profile)
+	}
+	resolver.Add(vars.OriginLock, cfgViper.GetString("from-lock"), lock.Vars)
+	for _, path := range cfgViper.GetStringSlice("var-file") {
+		vs, err := vars.ReadFile(path)
+		if err != nil {
+			logrus.Fatalf("failed to load variable file: %s", err)
+		}
+		resolver.Add(vars.OriginFile, path, vs)
+	}
+	envVars, err := vars.FromEnv(os.Environ())
+	if err != nil {
+		return err
+	}
+	resolver.Add(vars.OriginEnv, "", envVars)
+	flagVars := vars.VarSet{}
 	for _, raw := range cfgViper.GetStringSlice("variable") {
 		k, v, err := vars.ToVar(raw)
 		if err != nil {
@@ -112,33 +189,97 @@ func create(cmd *cobra.Command, args []string) error {
 		if k == "" {
 			logrus.Fatal("variables should be in the format <key>=<value>")
 		}
-		corr.Vars[k] = v
+		flagVars[k] = v
 	}
-	for k, v := range cfg.Vars { // copy the global vars for future reference
-		corr.Vars[k] = v
+	resolver.Add(vars.OriginFlag, "", flagVars)
+	corr.Vars, corr.VarOrigins = resolver.Resolve()
+
+	// sensitive variables are not stored in lock files
+	if missing := lock.MissingSensitiveVars(corr.Vars); len(missing) > 0 {
+		logrus.Fatalf("the lock file does not store sensitive variables, set %s with -v, --var-file or %s<name>",
+			strings.Join(missing, ", "), vars.EnvPrefix)
 	}
 
 	// load the package
 	logrus.Info("loading package")
-	pkg, err := _package.LoadPackage(corr.Source)
+	var loadOpts []_package.LoadOption
+	if cfgViper.IsSet("offline") {
+		loadOpts = append(loadOpts, _package.WithOffline(cfgViper.GetBool("offline")))
+	}
+
+	pkg, err := _package.LoadPackage(corr.Source, loadOpts...)
 	if err != nil {
 		logrus.Fatalf("failed to load package: %s", err)
 	}
 
-	// update the corral ref to the absolute path
+	// record the original reference and update the corral source to the absolute path
+	corr.Reference = corr.Source
+	corr.Digest = pkg.Digest
 	corr.Source = pkg.RootPath
 
+	// use the terraform version the package was published with unless the lock pins one
+	if corr.TerraformVersion == "" {
+		corr.TerraformVersion = pkg.TerraformVersion()
+	}
+
+	// prompt for missing required variables
+	if !cfgViper.GetBool("no-input") && canPrompt() {
+		answers, err := promptVars(pkg, corr.Vars)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		if len(answers) > 0 {
+			resolver.Add(vars.OriginPrompt, "", answers)
+			corr.Vars, corr.VarOrigins = resolver.Resolve()
+
+			if cfgViper.GetBool("save-answers") {
+				if cfg.Vars == nil {
+					cfg.Vars = map[string]any{}
+				}
+				// sensitive answers are not written to the global config in plain text
+				for k, v := range answers {
+					if pkg.VariableSchemas[k].Sensitive {
+						logrus.Warnf("not saving sensitive variable [%s] to the global variables", k)
+						continue
+					}
+					cfg.Vars[k] = v
+				}
+				if err = cfg.Save(); err != nil {
+					logrus.Fatal("failed to save global variables: ", err)
+				}
+			}
+		}
+	}
+
 	// validate the variables
 	err = pkg.ValidateVarSet(corr.Vars, true)
 	if err != nil {
 		logrus.Fatal("invalid variables: ", err)
 	}
 
-	err = pkg.ApplyDefaultVars(corr.Vars)
-	if err != nil {
-		logrus.Fatal("invalid defaults: ", err)
+	if lockPath := cfgViper.GetString("lock"); lockPath != "" {
+		lock = corral.Lock{
+			Package:          pkg.PinnedReference(),
+			TerraformVersion: corr.TerraformVersion,
+			Vars:             pkg.FilterSensitiveVars(pkg.FilterVars(corr.Vars)),
+		}
+		for k := range pkg.FilterVars(corr.Vars) {
+			if pkg.VariableSchemas[k].Sensitive {
+				lock.SensitiveVars = append(lock.SensitiveVars, k)
+			}
+		}
+		sort.Strings(lock.SensitiveVars)
+
+		if err = lock.Save(lockPath); err != nil {
+			logrus.Fatal("failed to write lock file: ", err)
+		}
 	}
 
+	resolver.Add(vars.OriginDefault, "", pkg.DefaultVars())
+	corr.Vars, corr.VarOrigins = resolver.Resolve()
+	generated := vars.Origin{Kind: vars.OriginCorral}
+
 	if corr.Vars["corral_private_key"] == nil && corr.Vars["corral_public_key"] == nil {
 		logrus.Info("generating ssh keys")
 		if corr.Vars["corral_ssh_key_type"] == ed25519KeyType {
@@ -153,7 +294,7 @@ func create(cmd *cobra.Command, args []string) error {
 			corr.PrivateKey = string(encodePrivateKeyToPEM(privkey, "OPENSSH"))
 			corr.PublicKey = string(ssh.MarshalAuthorizedKey(pubkey))
 		} else {
-			corr.Vars["corral_ssh_key_type"] = "rsa"
+			corr.SetVar("corral_ssh_key_type", "rsa", generated)
 			privkey, err := generateRSAPrivateKey(2048)
 			if err != nil {
 				logrus.Fatal("unable to generate private rsa key: ", err)
@@ -165,8 +306,8 @@ func create(cmd *cobra.Command, args []string) error {
 			corr.PrivateKey = string(encodePrivateKeyToPEM(privkey, "RSA"))
 			corr.PublicKey = string(pubkey)
 		}
-		corr.Vars["corral_public_key"] = corr.PublicKey
-		corr.Vars["corral_private_key"] = corr.PrivateKey
+		corr.SetVar("corral_public_key", corr.PublicKey, generated)
+		corr.SetVar("corral_private_key", corr.PrivateKey, generated)
 	} else {
 		logrus.Info("reusing generated ssh keys")
 		corr.PublicKey = corr.Vars["corral_public_key"].(string)
@@ -177,10 +318,10 @@ func create(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		logrus.Error("failed to read user public key: ", err)
 	}
-	corr.Vars["corral_name"] = corr.Name
-	corr.Vars["corral_user_id"] = cfg.UserID
-	corr.Vars["corral_user_public_key"] = string(userPublicKey)
-	corr.Vars["corral_node_pools"] = ""
+	corr.SetVar("corral_name", corr.Name, generated)
+	corr.SetVar("corral_user_id", cfg.UserID, generated)
+	corr.SetVar("corral_user_public_key", string(userPublicKey), generated)
+	corr.SetVar("corral_node_pools", "", generated)
 
 	// write the corral to disk
 	corr.SetStatus(corral.StatusProvisioning)
@@ -230,7 +371,7 @@ func create(cmd *cobra.Command, args []string) error {
 				}
 			}
 
-			err = executeShellCommand(cmd.Command, shells, corr.Vars, *cmd.Parallel)
+			err = executeShellCommand(cmd.Command, shells, &corr, *cmd.Parallel)
 		}
 
 		if err != nil {
@@ -322,12 +463,12 @@ func copyPackageFiles(shells []*shell.Shell, pkg _package.Package) error {
 	return wg.Wait()
 }
 
-func executeShellCommand(command string, shells []*shell.Shell, vs vars.VarSet, parallel bool) error {
+func executeShellCommand(command string, shells []*shell.Shell, corr *corral.Corral, parallel bool) error {
 	var err error
 	if parallel {
-		err = executeShellCommandAsync(command, shells, vs)
+		err = executeShellCommandAsync(command, shells, corr)
 	} else {
-		err = executeShellCommandSync(command, shells, vs)
+		err = executeShellCommandSync(command, shells, corr)
 	}
 	if err != nil {
 		return errors.Wrapf(err, "running %s", command)
@@ -335,9 +476,9 @@ func executeShellCommand(command string, shells []*shell.Shell, vs vars.VarSet,
 	return nil
 }
 
-// executeShellCommandAsync runs the given command on the given shells. Any vars set are saved to the VarSet.
+// executeShellCommandAsync runs the given command on the given shells. Any vars set are saved to the corral.
 // Concurrency is limited to the number of cpus on the user's machine.
-func executeShellCommandAsync(command string, shells []*shell.Shell, vs vars.VarSet) error {
+func executeShellCommandAsync(command string, shells []*shell.Shell, corr *corral.Corral) error {
 	var mu sync.Mutex
 	var wg errgroup.Group
 	sem := make(chan bool, runtime.NumCPU())
@@ -354,9 +495,7 @@ func executeShellCommandAsync(command string, shells []*shell.Shell, vs vars.Var
 			}
 
 			mu.Lock()
-			for k, v := range sh.Vars {
-				vs[k] = v
-			}
+			setNodeVars(corr, sh)
 			mu.Unlock()
 
 			<-sem
@@ -367,7 +506,7 @@ func executeShellCommandAsync(command string, shells []*shell.Shell, vs vars.Var
 	return wg.Wait()
 }
 
-func executeShellCommandSync(command string, shells []*shell.Shell, vs vars.VarSet) error {
+func executeShellCommandSync(command string, shells []*shell.Shell, corr *corral.Corral) error {
 	for _, sh := range shells {
 		sh := sh
 		err := sh.Run(command)
@@ -375,14 +514,19 @@ func executeShellCommandSync(command string, shells []*shell.Shell, vs vars.VarS
 			return err
 		}
 
-		for k, v := range sh.Vars {
-			vs[k] = v
-		}
+		setNodeVars(corr, sh)
 	}
 
 	return nil
 }
 
+// setNodeVars saves the vars set by the shell's last command to the corral.
+func setNodeVars(corr *corral.Corral, sh *shell.Shell) {
+	for k, v := range sh.Outputs {
+		corr.SetVar(k, v, vars.Origin{Kind: vars.OriginNode, Source: sh.Node.Name})
+	}
+}
+
 func generateRSAPrivateKey(bits int) (*rsa.PrivateKey, error) {
 	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
 	if err != nil {
@@ -0,0 +1,34 @@
+package cmd_package
+
+import (
+	_package "github.com/rancherlabs/corral/pkg/package"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const copyDescription = `
+Copy a package, and any signatures of it, from one OCI registry to another.  The package is copied without changes so
+its digest and annotations are preserved.  If the destination does not have a tag the source tag is used.
+
+Examples:
+corral package copy ghcr.io/rancher/my_pkg:latest registry.example.com/rancher/my_pkg
+`
+
+func NewCommandCopy() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "copy SOURCE DESTINATION",
+		Short: "Copy a package between OCI registries.",
+		Long:  copyDescription,
+		Args:  cobra.ExactArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if err := _package.CopyPackage(args[0], args[1]); err != nil {
+				return err
+			}
+
+			logrus.Infof("copied %s to %s", args[0], args[1])
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,122 @@
+package cmd_package
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	pkgcmd "github.com/rancherlabs/corral/pkg/cmd"
+	_package "github.com/rancherlabs/corral/pkg/package"
+	"github.com/spf13/cobra"
+)
+
+const diffDescription = `
+Compare two packages.  Annotation, variable and command changes are read from the manifests and the terraform modules
+and overlays are compared file by file.  Variables that are required by B but were not required by A are listed
+separately.
+
+Examples:
+corral package diff ghcr.io/rancherlabs/corral/k3s:v1.24 ghcr.io/rancherlabs/corral/k3s:v1.25
+corral package diff ghcr.io/rancherlabs/corral/k3s:latest ./k3s
+corral package diff --fail-on-new-required -o json ghcr.io/rancherlabs/corral/k3s:v1.24 ghcr.io/rancherlabs/corral/k3s:v1.25
+`
+
+func NewCommandDiff() *cobra.Command {
+	output := pkgcmd.OutputFormatTable
+	var failOnRequired bool
+
+	cmd := &cobra.Command{
+		Use:   "diff A B",
+		Short: "Compare two packages.",
+		Long:  diffDescription,
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var pkgs [2]_package.Package
+			for i, ref := range args {
+				pkg, err := _package.LoadPackage(ref, offlineOptions(cmd)...)
+				if err != nil {
+					return fmt.Errorf("failed to load %s: %w", ref, err)
+				}
+				pkgs[i] = pkg
+			}
+
+			d, err := _package.DiffPackages(pkgs[0], pkgs[1])
+			if err != nil {
+				return err
+			}
+
+			var out string
+			if output == pkgcmd.OutputFormatTable {
+				out = diffTable(d)
+			} else if out, err = pkgcmd.OutputRows(d, nil, nil, output); err != nil {
+				return err
+			}
+			fmt.Println(out)
+
+			if failOnRequired && len(d.NewRequiredVariables) > 0 {
+				return fmt.Errorf("%s requires new variables: %s", args[1], strings.Join(d.NewRequiredVariables, ", "))
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("offline", false, "Load the packages from the package cache without contacting the registry.")
+	cmd.Flags().VarP(&output, "output", "o", "Output format. One of: table|json|yaml")
+	cmd.Flags().BoolVar(&failOnRequired, "fail-on-new-required", false, "Exit with an error if B requires variables A did not require.")
+
+	return cmd
+}
+
+// diffTable renders a table for each kind of change.
+func diffTable(d _package.PackageDiff) string {
+	if d.Empty() {
+		return "packages are identical"
+	}
+
+	var b strings.Builder
+
+	section := func(header table.Row, changes []_package.Change, row func(c _package.Change) table.Row) {
+		if len(changes) == 0 {
+			return
+		}
+
+		tbl := table.NewWriter()
+		tbl.AppendHeader(header)
+		tbl.AppendSeparator()
+		for _, c := range changes {
+			tbl.AppendRow(row(c))
+		}
+		b.WriteString(tbl.Render() + "\n\n")
+	}
+
+	if len(d.NewRequiredVariables) > 0 {
+		b.WriteString(fmt.Sprintf("new required variables: %s\n\n", strings.Join(d.NewRequiredVariables, ", ")))
+	}
+
+	section(table.Row{"ANNOTATION", "CHANGE", "OLD", "NEW"}, d.Annotations, func(c _package.Change) table.Row {
+		return table.Row{c.Name, c.Kind, diffValue(c.Old), diffValue(c.New)}
+	})
+	section(table.Row{"VARIABLE", "CHANGE", "FIELD", "OLD", "NEW"}, d.Variables, func(c _package.Change) table.Row {
+		if c.Field == "" {
+			return table.Row{c.Name, c.Kind, "", "", ""}
+		}
+		return table.Row{c.Name, c.Kind, c.Field, diffValue(c.Old), diffValue(c.New)}
+	})
+	section(table.Row{"COMMAND", "CHANGE", "OLD POSITION", "NEW POSITION"}, d.Commands, func(c _package.Change) table.Row {
+		return table.Row{c.Name, c.Kind, diffValue(c.Old), diffValue(c.New)}
+	})
+	section(table.Row{"FILE", "CHANGE"}, d.Files, func(c _package.Change) table.Row {
+		return table.Row{c.Name, c.Kind}
+	})
+
+	return strings.TrimSuffix(b.String(), "\n\n")
+}
+
+func diffValue(v any) string {
+	if v == nil {
+		return ""
+	}
+
+	return defaultString(v)
+}
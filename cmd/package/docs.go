@@ -0,0 +1,54 @@
+package cmd_package
+
+import (
+	"io"
+	"os"
+
+	_package "github.com/rancherlabs/corral/pkg/package"
+	"github.com/spf13/cobra"
+)
+
+const docsDescription = `
+Generate documentation for a package from its manifest.  The documentation includes the package's description,
+annotations, command flow, node pools and a table of its variables.
+
+Examples:
+corral package docs ./my-package > README.md
+corral package docs --format html --out index.html ghcr.io/rancherlabs/corral/k3s:latest
+`
+
+func NewCommandDocs() *cobra.Command {
+	format := _package.DocsFormatMarkdown
+	var out string
+
+	cmd := &cobra.Command{
+		Use:   "docs PACKAGE",
+		Short: "Generate markdown or html documentation for a package.",
+		Long:  docsDescription,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pkg, err := _package.InspectPackage(args[0], offlineOptions(cmd)...)
+			if err != nil {
+				return err
+			}
+
+			var w io.Writer = os.Stdout
+			if out != "" {
+				f, err := os.Create(out)
+				if err != nil {
+					return err
+				}
+				defer func() { _ = f.Close() }()
+				w = f
+			}
+
+			return _package.WritePackageDocs(w, pkg, format)
+		},
+	}
+
+	cmd.Flags().Var(&format, "format", "Documentation format. One of: markdown|html")
+	cmd.Flags().StringVar(&out, "out", "", "write the documentation to the given file instead of stdout")
+	cmd.Flags().Bool("offline", false, "Load the package from the package cache without contacting the registry.")
+
+	return cmd
+}
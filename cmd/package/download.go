@@ -28,11 +28,13 @@ func NewCommandDownload() *cobra.Command {
 		Args:  cobra.RangeArgs(1, 2),
 	}
 
+	cmd.Flags().Bool("offline", false, "Load the package from the package cache without contacting the registry.")
+
 	return cmd
 }
 
-func download(_ *cobra.Command, args []string) {
-	pkg, err := _package.LoadPackage(args[0])
+func download(cmd *cobra.Command, args []string) {
+	pkg, err := _package.LoadPackage(args[0], append(offlineOptions(cmd), _package.WithDependencies(false))...)
 	if err != nil {
 		logrus.Fatalf("failed to load package: %s", err)
 	}
@@ -1,35 +1,145 @@
 package cmd_package
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	pkgcmd "github.com/rancherlabs/corral/pkg/cmd"
 	_package "github.com/rancherlabs/corral/pkg/package"
-	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
 )
 
+const infoDescription = `
+Display details about the given package.  Packages in an OCI registry are inspected without downloading their modules.
+
+Examples:
+corral package info ghcr.io/rancherlabs/corral/k3s:latest
+corral package info -o json ./my-package | jq '.variables[] | select(.required) | .name'
+`
+
 func NewCommandInfo() *cobra.Command {
+	output := pkgcmd.OutputFormatTable
+
 	cmd := &cobra.Command{
 		Use:   "info PACKAGE",
 		Short: "Display details about the given package.",
+		Long:  infoDescription,
 		Args:  cobra.ExactArgs(1),
-		Run:   info,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pkg, err := _package.InspectPackage(args[0], offlineOptions(cmd)...)
+			if err != nil {
+				return err
+			}
+
+			info := pkg.Info()
+
+			var out string
+			if output == pkgcmd.OutputFormatTable {
+				out = infoTable(info)
+			} else if out, err = pkgcmd.OutputRows(info, nil, nil, output); err != nil {
+				return err
+			}
+
+			fmt.Println(out)
+			return nil
+		},
 	}
 
+	cmd.Flags().Bool("offline", false, "Load the package from the package cache without contacting the registry.")
+	cmd.Flags().VarP(&output, "output", "o", "Output format. One of: table|json|yaml")
+
 	return cmd
 }
 
-func info(_ *cobra.Command, args []string) {
-	pkg, err := _package.LoadPackage(args[0])
-	if err != nil {
-		logrus.Fatal(err)
+// infoTable renders the package details followed by a table for each section.
+func infoTable(info _package.PackageInfo) string {
+	var b strings.Builder
+
+	b.WriteString(info.Name + "\n")
+	for _, line := range [][2]string{
+		{"Reference", info.Reference},
+		{"Digest", info.Digest},
+		{"Publisher", info.Publisher},
+		{"Published At", info.PublishedAt},
+	} {
+		if line[1] != "" {
+			b.WriteString(fmt.Sprintf("%s: %s\n", line[0], line[1]))
+		}
+	}
+
+	if info.Description != "" {
+		b.WriteString("\n" + info.Description + "\n")
+	}
+
+	section := func(header table.Row, rows []table.Row) {
+		if len(rows) == 0 {
+			return
+		}
+
+		tbl := table.NewWriter()
+		tbl.AppendHeader(header)
+		tbl.AppendSeparator()
+		tbl.AppendRows(rows)
+		b.WriteString("\n" + tbl.Render() + "\n")
 	}
 
-	println(pkg.Name)
-	println()
-	println(pkg.Description)
-	println()
-	println("VARIABLE\tDESCRIPTION")
-	for k, v := range pkg.VariableSchemas {
-		println(k, "\t", v.Description)
+	annotations := maps.Keys(info.Annotations)
+	sort.Strings(annotations)
+
+	var rows []table.Row
+	for _, k := range annotations {
+		rows = append(rows, table.Row{k, info.Annotations[k]})
 	}
-	println()
+	section(table.Row{"ANNOTATION", "VALUE"}, rows)
+
+	rows = nil
+	for i, c := range info.Commands {
+		if c.Module != "" {
+			rows = append(rows, table.Row{i + 1, "module", c.Module, "", ""})
+			continue
+		}
+
+		parallel := c.Parallel == nil || *c.Parallel
+		rows = append(rows, table.Row{i + 1, "command", c.Command, strings.Join(c.NodePoolNames, ", "), parallel})
+	}
+	section(table.Row{"#", "TYPE", "COMMAND", "NODE POOLS", "PARALLEL"}, rows)
+
+	rows = nil
+	for _, p := range info.NodePools {
+		overlay := "overlay"
+		if p.Overlay != "" {
+			overlay += "/" + p.Overlay
+		}
+		rows = append(rows, table.Row{p.Name, overlay})
+	}
+	section(table.Row{"NODE POOL", "OVERLAY"}, rows)
+
+	rows = nil
+	for _, v := range info.Variables {
+		rows = append(rows, table.Row{v.Name, v.Type, defaultString(v.Default), v.Required, v.ReadOnly, v.Sensitive, v.Description})
+	}
+	section(table.Row{"VARIABLE", "TYPE", "DEFAULT", "REQUIRED", "READ ONLY", "SENSITIVE", "DESCRIPTION"}, rows)
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func defaultString(v any) string {
+	if v == nil {
+		return ""
+	}
+
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(buf)
 }
@@ -0,0 +1,43 @@
+package cmd_package
+
+import (
+	"fmt"
+	"strings"
+
+	_package "github.com/rancherlabs/corral/pkg/package"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const initDescription = `
+Create a new package in the given directory.  The package is named after the directory and contains a terraform module
+that declares every variable corral sets, creates nodes with the given provider and returns them as a node pool, and
+an overlay script that runs on each node.
+
+Examples:
+corral package init my-package
+corral package init --provider aws my-package
+`
+
+func NewCommandInit() *cobra.Command {
+	var provider string
+
+	cmd := &cobra.Command{
+		Use:   "init NAME",
+		Short: "Create a new package.",
+		Long:  initDescription,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if err := _package.InitPackage(args[0], provider); err != nil {
+				return err
+			}
+
+			logrus.Infof("created %s package in %s", provider, args[0])
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&provider, "provider", "docker", fmt.Sprintf("provider the package creates nodes with (%s)", strings.Join(_package.ScaffoldProviders, "|")))
+
+	return cmd
+}
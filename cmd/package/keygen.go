@@ -0,0 +1,26 @@
+package cmd_package
+
+import (
+	_package "github.com/rancherlabs/corral/pkg/package"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func NewCommandKeygen() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "keygen PATH",
+		Short: "Generate a key pair for signing packages.",
+		Long:  "Generate an ed25519 key pair for signing packages. The public key is written to PATH.pub.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if err := _package.GenerateSigningKey(args[0]); err != nil {
+				return err
+			}
+
+			logrus.Infof("wrote %s and %s.pub", args[0], args[0])
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,52 @@
+package cmd_package
+
+import (
+	_package "github.com/rancherlabs/corral/pkg/package"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const loadDescription = `
+Load the packages in an archive written by "corral package save" into the package cache, or push them to an OCI
+registry.  Loaded packages can be used offline by the reference they were saved under.
+
+Examples:
+corral package load my_pkg.tar
+corral package load my_pkg.tar --push registry.example.com/rancher/my_pkg:latest
+`
+
+func NewCommandLoad() *cobra.Command {
+	var push string
+
+	cmd := &cobra.Command{
+		Use:   "load ARCHIVE",
+		Short: "Load packages from an OCI image layout archive.",
+		Long:  loadDescription,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if push != "" {
+				if err := _package.PushPackageArchive(args[0], push); err != nil {
+					return err
+				}
+
+				logrus.Infof("pushed %s to %s", args[0], push)
+				return nil
+			}
+
+			refs, err := _package.ImportPackageArchive(args[0])
+			if err != nil {
+				return err
+			}
+
+			for _, ref := range refs {
+				logrus.Infof("loaded %s", ref)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&push, "push", "", "Push the package to the given reference instead of loading it into the cache.")
+
+	return cmd
+}
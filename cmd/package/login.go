@@ -1,23 +1,40 @@
 package cmd_package
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
 	_package "github.com/rancherlabs/corral/pkg/package"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
+const loginDescription = `
+Login to an OCI registry.
+
+Credentials are stored in the credential store or credential helpers configured in the docker config
+(~/.docker/config.json) if there are any, otherwise they are stored in ~/.corral/registry-creds.json.  Credentials from
+docker login are used for registries corral has not logged in to.
+`
+
 func NewCommandLogin() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "login REGISTRY",
 		Short: "Login to an OCI registry.",
-		Args:  cobra.ExactArgs(1),
-		Run:   login,
+		Long:  loginDescription,
+		Example: "corral package login ghcr.io --username rancher\n" +
+			"echo $TOKEN | corral package login ghcr.io --username rancher --password-stdin",
+		Args: cobra.ExactArgs(1),
+		Run:  login,
 	}
 
 	cmd.Flags().String("username", "", "The username for the registry.")
 	cmd.Flags().String("password", "", "The password for the user.")
+	cmd.Flags().Bool("password-stdin", false, "Read the password from stdin.")
 
 	return cmd
 }
@@ -25,13 +42,33 @@ func NewCommandLogin() *cobra.Command {
 func login(cmd *cobra.Command, args []string) {
 	username, _ := cmd.Flags().GetString("username")
 	password, _ := cmd.Flags().GetString("password")
+	passwordStdin, _ := cmd.Flags().GetBool("password-stdin")
+
+	stdin := bufio.NewReader(os.Stdin)
+
+	if passwordStdin {
+		if password != "" {
+			logrus.Fatal("--password and --password-stdin are mutually exclusive")
+		}
+		if username == "" {
+			logrus.Fatal("--password-stdin requires --username")
+		}
+
+		buf, err := io.ReadAll(stdin)
+		if err != nil {
+			logrus.Fatalf("failed to read password from stdin: %s", err)
+		}
+		password = strings.TrimRight(string(buf), "\r\n")
+	} else if password != "" {
+		logrus.Warn("using --password on the command line is insecure, use --password-stdin")
+	}
 
 	if username == "" {
-		username = prompt("username: ")
+		username = prompt(stdin, "username: ")
 	}
 
 	if password == "" {
-		password = prompt("password: ")
+		password = promptPassword(stdin, "password: ")
 	}
 
 	err := _package.AddRegistryCredentials(args[0], username, password)
@@ -42,11 +79,27 @@ func login(cmd *cobra.Command, args []string) {
 	logrus.Info("success!")
 }
 
-func prompt(message string) string {
-	var buf string
+func prompt(stdin *bufio.Reader, message string) string {
+	print(message)
+
+	line, _ := stdin.ReadString('\n')
+
+	return strings.TrimRight(line, "\r\n")
+}
+
+// promptPassword prompts for a password without echoing it if stdin is a terminal.
+func promptPassword(stdin *bufio.Reader, message string) string {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return prompt(stdin, message)
+	}
 
 	print(message)
-	_, _ = fmt.Scanln(&buf)
+	buf, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		logrus.Fatalf("failed to read password: %s", err)
+	}
 
-	return buf
+	return string(buf)
 }
@@ -0,0 +1,31 @@
+package cmd_package
+
+import (
+	"errors"
+
+	_package "github.com/rancherlabs/corral/pkg/package"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func NewCommandLogout() *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout REGISTRY",
+		Short: "Remove the stored credentials of an OCI registry.",
+		Args:  cobra.ExactArgs(1),
+		Run:   logout,
+	}
+}
+
+func logout(_ *cobra.Command, args []string) {
+	err := _package.RemoveRegistryCredentials(args[0])
+	if errors.Is(err, _package.ErrNotLoggedIn) {
+		logrus.Warnf("%s, credentials from docker login are removed with docker logout", err)
+		return
+	}
+	if err != nil {
+		logrus.Fatalf("failed to remove credentials: %s", err)
+	}
+
+	logrus.Infof("removed credentials for %s", args[0])
+}
@@ -0,0 +1,92 @@
+package cmd_package
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	_package "github.com/rancherlabs/corral/pkg/package"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const mirrorDescription = `
+Mirror packages to another OCI registry.  Each package is copied to the same repository under the target, e.g.
+ghcr.io/rancher/my_pkg:latest mirrored to registry.example.com/mirror is copied to
+registry.example.com/mirror/rancher/my_pkg:latest.  References can be given as arguments or read from a file with one
+reference per line.
+
+Examples:
+corral package mirror --to registry.example.com/mirror ghcr.io/rancher/my_pkg:latest ghcr.io/rancher/other_pkg:^1.2
+corral package mirror --to registry.example.com/mirror -f packages.txt
+`
+
+func NewCommandMirror() *cobra.Command {
+	var target, file string
+
+	cmd := &cobra.Command{
+		Use:   "mirror --to TARGET [REFERENCE...]",
+		Short: "Mirror packages to another OCI registry.",
+		Long:  mirrorDescription,
+		RunE: func(_ *cobra.Command, args []string) error {
+			refs := args
+			if file != "" {
+				fileRefs, err := readRefs(file)
+				if err != nil {
+					return err
+				}
+				refs = append(refs, fileRefs...)
+			}
+
+			if len(refs) == 0 {
+				return fmt.Errorf("no packages to mirror")
+			}
+
+			var failed []string
+			for _, ref := range refs {
+				dst, err := _package.MirrorPackage(ref, target)
+				if err != nil {
+					logrus.Errorf("failed to mirror %s: %s", ref, err)
+					failed = append(failed, ref)
+					continue
+				}
+
+				logrus.Infof("mirrored %s to %s", ref, dst)
+			}
+
+			if len(failed) > 0 {
+				return fmt.Errorf("failed to mirror %d of %d packages: %s", len(failed), len(refs), strings.Join(failed, ", "))
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&target, "to", "", "Registry and optional path to mirror packages to.")
+	_ = cmd.MarkFlagRequired("to")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Read references from a file, one per line.  Lines starting with # are ignored.")
+
+	return cmd
+}
+
+func readRefs(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var refs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		refs = append(refs, line)
+	}
+
+	return refs, scanner.Err()
+}
@@ -1,6 +1,7 @@
 package cmd_package
 
 import (
+	_package "github.com/rancherlabs/corral/pkg/package"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -17,11 +18,34 @@ func NewCommandPackage() *cobra.Command {
 	}
 
 	cmd.AddCommand(
+		NewCommandInit(),
 		NewCommandPublish(),
 		NewCommandLogin(),
+		NewCommandLogout(),
 		NewCommandInfo(),
+		NewCommandDocs(),
+		NewCommandDiff(),
 		NewCommandValidate(),
+		NewCommandTest(),
 		NewCommandDownload(),
-		NewCommandTemplate())
+		NewCommandTemplate(),
+		NewCommandKeygen(),
+		NewCommandSign(),
+		NewCommandVerify(),
+		NewCommandTags(),
+		NewCommandSave(),
+		NewCommandLoad(),
+		NewCommandCopy(),
+		NewCommandMirror())
 	return cmd
 }
+
+// offlineOptions returns the load options for the offline flag if it was set, otherwise the config default is used.
+func offlineOptions(cmd *cobra.Command) []_package.LoadOption {
+	if !cmd.Flags().Changed("offline") {
+		return nil
+	}
+
+	offline, _ := cmd.Flags().GetBool("offline")
+	return []_package.LoadOption{_package.WithOffline(offline)}
+}
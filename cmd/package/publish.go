@@ -13,6 +13,9 @@ import (
 const publishDescription = `
 Upload the package found at the given target to the given registry.
 
+Files matching the patterns in the package's .corralignore file are not published.  The syntax is the same as
+.gitignore and patterns are relative to the package root.  Terraform's .terraform directory is never published.
+
 Examples:
 corral publish /home/rancher/my_pkg ghcr.io/rancher/my_pkg:latest
 `
@@ -32,22 +35,40 @@ func NewCommandPublish() *cobra.Command {
 func publish(_ *cobra.Command, args []string) {
 	cfg := config.MustLoad()
 
-	pkg, err := _package.LoadPackage(args[0])
+	pkg, err := _package.LoadPackage(args[0], _package.WithDependencies(false))
 	if err != nil {
 		logrus.Fatal("failed to load package: ", err)
 	}
 
+	annotatePackage(pkg, cfg)
+	warnPackageFiles(pkg)
+
+	err = _package.UploadPackage(pkg, args[1])
+	if err != nil {
+		logrus.Fatal("failed to push package: ", err)
+	}
+
+	logrus.Info("success")
+}
+
+// annotatePackage sets the annotations recorded when a package is published.
+func annotatePackage(pkg _package.Package, cfg config.Config) {
 	setAnnotationIfEmpty(pkg.Annotations, _package.TerraformVersionAnnotation, version.TerraformVersion)
 	setAnnotationIfEmpty(pkg.Annotations, _package.PublisherAnnotation, cfg.UserID)
 	setAnnotationIfEmpty(pkg.Annotations, _package.CorralVersionAnnotation, version.Version)
 	setAnnotationIfEmpty(pkg.Annotations, _package.PublishTimestampAnnotation, time.Now().UTC().Format(time.RFC3339))
+}
 
-	err = _package.UploadPackage(pkg, args[1])
+// warnPackageFiles warns about files in the package that look like they should not be published.
+func warnPackageFiles(pkg _package.Package) {
+	warnings, err := _package.PackageWarnings(pkg)
 	if err != nil {
-		logrus.Fatal("failed to push package: ", err)
+		logrus.Warnf("failed to check package files: %s", err)
 	}
 
-	logrus.Info("success")
+	for _, warning := range warnings {
+		logrus.Warnf("%s, add it to %s if it should not be published", warning, _package.IgnoreFile)
+	}
 }
 
 func setAnnotationIfEmpty(annotations map[string]string, key, value string) {
@@ -0,0 +1,65 @@
+package cmd_package
+
+import (
+	"os"
+
+	"github.com/rancherlabs/corral/pkg/config"
+	_package "github.com/rancherlabs/corral/pkg/package"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const saveDescription = `
+Save a package to an OCI image layout archive.  The package can be a local directory or a reference to a package in an
+OCI registry.  Signatures of remote packages are saved with the package.
+
+Examples:
+corral package save ghcr.io/rancher/my_pkg:latest -o my_pkg.tar
+corral package save /home/rancher/my_pkg --tag ghcr.io/rancher/my_pkg:dev -o my_pkg.tar
+`
+
+func NewCommandSave() *cobra.Command {
+	var output, tag string
+
+	cmd := &cobra.Command{
+		Use:   "save PACKAGE",
+		Short: "Save a package to an OCI image layout archive.",
+		Long:  saveDescription,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if info, err := os.Stat(args[0]); err == nil && info.IsDir() {
+				pkg, err := _package.LoadPackage(args[0], _package.WithDependencies(false))
+				if err != nil {
+					return err
+				}
+
+				cfg, err := config.Load()
+				if err != nil {
+					return err
+				}
+				annotatePackage(pkg, cfg)
+				warnPackageFiles(pkg)
+
+				if tag == "" {
+					tag = pkg.Name + ":latest"
+				}
+
+				err = _package.SavePackage(pkg, tag, output)
+				if err != nil {
+					return err
+				}
+			} else if err = _package.SaveRemotePackage(args[0], output); err != nil {
+				return err
+			}
+
+			logrus.Infof("saved %s to %s", args[0], output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Path of the archive to write.")
+	_ = cmd.MarkFlagRequired("output")
+	cmd.Flags().StringVar(&tag, "tag", "", "Reference to store a local package under, defaults to NAME:latest.")
+
+	return cmd
+}
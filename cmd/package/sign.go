@@ -0,0 +1,40 @@
+package cmd_package
+
+import (
+	_package "github.com/rancherlabs/corral/pkg/package"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const signDescription = `
+Sign a package in an OCI registry with a local ed25519 key. The signature is pushed to the same repository with the
+tag sha256-<digest>.sig.
+
+Examples:
+corral package keygen corral.key
+corral package sign --key corral.key ghcr.io/rancher/my_pkg:latest
+`
+
+func NewCommandSign() *cobra.Command {
+	var key string
+
+	cmd := &cobra.Command{
+		Use:   "sign REFERENCE",
+		Short: "Sign a package in an OCI registry.",
+		Long:  signDescription,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if err := _package.SignPackage(args[0], key); err != nil {
+				return err
+			}
+
+			logrus.Info("success")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&key, "key", "k", "", "Path to the private key used to sign the package.")
+	_ = cmd.MarkFlagRequired("key")
+
+	return cmd
+}
@@ -0,0 +1,38 @@
+package cmd_package
+
+import (
+	"fmt"
+
+	_package "github.com/rancherlabs/corral/pkg/package"
+	"github.com/spf13/cobra"
+)
+
+const tagsDescription = `
+List the tags of a package repository in an OCI registry.
+
+Examples:
+corral package tags ghcr.io/rancher/my_pkg
+`
+
+func NewCommandTags() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tags REPOSITORY",
+		Short: "List the tags of a package repository.",
+		Long:  tagsDescription,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			tags, err := _package.ListTags(args[0])
+			if err != nil {
+				return err
+			}
+
+			for _, tag := range tags {
+				fmt.Println(tag)
+			}
+
+			return nil
+		},
+	}
+
+	return cmd
+}
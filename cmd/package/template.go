@@ -1,6 +1,8 @@
 package cmd_package
 
 import (
+	"fmt"
+
 	_package "github.com/rancherlabs/corral/pkg/package"
 	"github.com/spf13/cobra"
 )
@@ -8,25 +10,70 @@ import (
 const templateDescription = `
 Create a package from existing package(s).
 
+A template config file can declare the name, description and packages of the rendered package along with extra
+variables, default overrides and renamed node pools.  The name and packages in the config file are relative to the
+config file.  Packages given as arguments are added to the packages in the config file and the last argument overrides
+the name.
+
+name: my-package
+description: my description
+packages:
+  - ../simple
+  - ghcr.io/rancherlabs/corral/k3s:latest
+variables:
+  extra:
+    type: string
+defaults:
+  var1: bar
+node_pools:
+  all: nodes
+
 Examples:
 corral package template a b c OUT 
 corral package template --description "my description" a b c OUT
+corral package template -f config.yaml
+corral package template -f config.yaml d OUT
 `
 
 func NewCommandTemplate() *cobra.Command {
-	var description string
+	var description, file string
 
 	cmd := &cobra.Command{
 		Use:   "template PACKAGE[S] NAME",
 		Short: "Create a package from a template",
 		Long:  templateDescription,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return _package.Template(args[len(args)-1], description, args[:len(args)-1]...)
+			if file == "" {
+				return _package.Template(args[len(args)-1], description, args[:len(args)-1]...)
+			}
+
+			cfg, err := _package.LoadTemplateConfig(file)
+			if err != nil {
+				return err
+			}
+
+			if len(args) > 0 {
+				cfg.Name = args[len(args)-1]
+				cfg.Packages = append(cfg.Packages, args[:len(args)-1]...)
+			}
+
+			if cmd.Flags().Changed("description") {
+				cfg.Description = description
+			}
+
+			return _package.RenderTemplate(cfg)
+		},
+		Args: func(cmd *cobra.Command, args []string) error {
+			if file == "" && len(args) < 2 {
+				return fmt.Errorf("requires at least 2 arg(s) or a template config file, only received %d", len(args))
+			}
+
+			return nil
 		},
-		Args: cobra.MinimumNArgs(2),
 	}
 
 	cmd.Flags().StringVarP(&description, "description", "d", "", "description of the rendered package")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "template config file declaring the rendered package")
 
 	return cmd
 }
@@ -0,0 +1,283 @@
+package cmd_package
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/rancherlabs/corral/pkg/config"
+	"github.com/rancherlabs/corral/pkg/corral"
+	_package "github.com/rancherlabs/corral/pkg/package"
+	"github.com/rancherlabs/corral/pkg/shell"
+	"github.com/rancherlabs/corral/pkg/vars"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
+)
+
+const testDescription = `
+Test a package by creating a corral for each case of a test matrix file, running the steps against it and deleting
+it.  Steps run a command on the nodes of the given node pools and compare the corral's variables to expected values
+or regular expressions.  A JUnit report is written if the junit flag is set.
+
+package: ./my-package
+cases:
+  - name: single
+    variables:
+      node_count: 1
+  - name: ha
+    variables:
+      node_count: 3
+    steps:
+      - name: node count
+        vars:
+          node_count: 3
+steps:
+  - name: docker is running
+    command: docker info
+    node_pools: [nodes]
+  - name: outputs
+    vars:
+      node_user: ubuntu
+    match:
+      node_hostname: ^node-
+
+Examples:
+corral package test -f corral-test.yaml
+corral package test -f corral-test.yaml --junit report.xml ./my-package
+`
+
+var errTestFailed = errors.New("package test failed")
+
+func NewCommandTest() *cobra.Command {
+	var file, junit string
+	var keep bool
+
+	cmd := &cobra.Command{
+		Use:   "test [PACKAGE]",
+		Short: "Test a package with a test matrix.",
+		Long:  testDescription,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			matrix, err := _package.LoadTestMatrix(file)
+			if err != nil {
+				return err
+			}
+
+			if len(args) > 0 {
+				matrix.Package = args[0]
+			}
+			if matrix.Package == "" {
+				return fmt.Errorf("the package must be set in the test matrix or as an argument")
+			}
+
+			results := runTestMatrix(matrix, keep)
+
+			if junit != "" {
+				f, err := os.Create(junit)
+				if err != nil {
+					return err
+				}
+				defer func() { _ = f.Close() }()
+
+				if err = _package.WriteJUnitReport(f, matrix.Package, results); err != nil {
+					return err
+				}
+			}
+
+			tbl := table.NewWriter()
+			tbl.AppendHeader(table.Row{"CASE", "STEP", "RESULT", "TIME"})
+			tbl.AppendSeparator()
+
+			failed := false
+			for _, r := range results {
+				result := "pass"
+				switch {
+				case r.Skipped:
+					result = "skip"
+				case r.Failure != "":
+					result = "fail"
+					failed = true
+				}
+
+				tbl.AppendRow(table.Row{r.Case, r.Step, result, r.Duration.Round(time.Second)})
+			}
+			fmt.Println(tbl.Render())
+
+			if failed {
+				return errTestFailed
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", "corral-test.yaml", "test matrix file")
+	cmd.Flags().StringVar(&junit, "junit", "", "write a JUnit report to the given file")
+	cmd.Flags().BoolVar(&keep, "keep", false, "do not delete the corrals after testing them")
+
+	return cmd
+}
+
+// runTestMatrix creates a corral for each case and runs the case's steps.  Steps are skipped if the corral could not
+// be created.
+func runTestMatrix(matrix _package.TestMatrix, keep bool) []_package.TestResult {
+	var results []_package.TestResult
+
+	for _, tc := range matrix.Cases {
+		name := "test-" + tc.Name + "-" + randomSuffix()
+		logrus.Infof("testing case [%s] with corral [%s]", tc.Name, name)
+
+		start := time.Now()
+		c, err := createTestCorral(name, matrix.Package, tc.Variables)
+		results = append(results, testResult(tc.Name, "create", start, err))
+
+		for _, step := range matrix.StepsFor(tc) {
+			if err != nil {
+				results = append(results, _package.TestResult{Case: tc.Name, Step: step.Name, Skipped: true})
+				continue
+			}
+
+			logrus.Infof("[%s] running step [%s]", tc.Name, step.Name)
+			start = time.Now()
+			results = append(results, testResult(tc.Name, step.Name, start, runTestStep(c, step)))
+		}
+
+		// corrals that failed to create were rolled back
+		if c == nil {
+			results = append(results, _package.TestResult{Case: tc.Name, Step: "delete", Skipped: true})
+			continue
+		}
+
+		if keep {
+			logrus.Infof("keeping corral [%s]", name)
+			continue
+		}
+
+		start = time.Now()
+		results = append(results, testResult(tc.Name, "delete", start, runCorral("delete", name)))
+	}
+
+	return results
+}
+
+// createTestCorral creates the corral with the corral binary, create exits on most errors.
+func createTestCorral(name, pkg string, variables map[string]any) (*corral.Corral, error) {
+	// missing variables fail the create step instead of prompting
+	args := []string{"create", "--no-input", name, pkg}
+	keys := maps.Keys(variables)
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, err := varString(variables[k])
+		if err != nil {
+			return nil, fmt.Errorf("variable [%s]: %w", k, err)
+		}
+
+		args = append(args, "-v", k+"="+v)
+	}
+
+	if err := runCorral(args...); err != nil {
+		return nil, err
+	}
+
+	// a corral that failed to create is rolled back without returning an error
+	c, err := corral.Load(config.CorralPath(name))
+	if err != nil {
+		return nil, fmt.Errorf("corral was not created: %w", err)
+	}
+
+	if c.Status != corral.StatusReady {
+		return c, fmt.Errorf("corral is %s", c.Status)
+	}
+
+	return c, nil
+}
+
+// runTestStep runs the step's command on each node of its node pools one at a time and then checks the variables.
+func runTestStep(c *corral.Corral, step _package.TestStep) error {
+	vs := vars.VarSet{}
+	for k, v := range c.Vars {
+		vs[k] = v
+	}
+
+	if step.Command != "" {
+		registry := shell.NewRegistry()
+		defer registry.Close()
+
+		for _, pool := range step.NodePools {
+			nodes := c.NodePools[pool]
+			if len(nodes) == 0 {
+				return fmt.Errorf("node pool [%s] has no nodes", pool)
+			}
+
+			for _, n := range nodes {
+				sh, err := registry.GetShell(n, c.PrivateKey, vs)
+				if err != nil {
+					return fmt.Errorf("failed to connect to node [%s]: %w", n.Name, err)
+				}
+
+				if err = sh.Run(step.Command); err != nil {
+					return fmt.Errorf("[%s] %s: %w", n.Name, step.Command, err)
+				}
+			}
+		}
+	}
+
+	if failures := step.CheckVars(vs); len(failures) > 0 {
+		return errors.New(strings.Join(failures, "\n"))
+	}
+
+	return nil
+}
+
+func runCorral(args ...string) error {
+	bin, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	if logrus.IsLevelEnabled(logrus.DebugLevel) {
+		args = append(args, "--debug")
+	}
+
+	cmd := exec.Command(bin, args...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+func testResult(tc, step string, start time.Time, err error) _package.TestResult {
+	r := _package.TestResult{Case: tc, Step: step, Duration: time.Since(start)}
+	if err != nil {
+		logrus.Errorf("[%s] %s failed: %s", tc, step, err)
+		r.Failure = err.Error()
+	}
+
+	return r
+}
+
+// varString formats a matrix variable the way corral create parses it.
+func varString(v any) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+
+	buf, err := json.Marshal(v)
+	return string(buf), err
+}
+
+func randomSuffix() string {
+	buf := make([]byte, 3)
+	_, _ = rand.Read(buf)
+
+	return hex.EncodeToString(buf)
+}
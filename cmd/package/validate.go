@@ -1,19 +1,66 @@
 package cmd_package
 
 import (
+	"fmt"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	pkgcmd "github.com/rancherlabs/corral/pkg/cmd"
 	_package "github.com/rancherlabs/corral/pkg/package"
 	"github.com/spf13/cobra"
 )
 
+const validateDescription = `
+Validate the given package's manifest and structure.
+
+The manifest is cross checked with the package's terraform modules and overlay.  Required terraform variables must be
+manifest variables, corral variables or outputs of a previous module, commands and overlays should target node pools
+returned by a module and overlays must point to existing directories.
+
+Examples:
+corral package validate ./my-package
+corral package validate -o json ./my-package
+`
+
 func NewCommandValidate() *cobra.Command {
+	output := pkgcmd.OutputFormatTable
+
 	cmd := &cobra.Command{
 		Use:   "validate PACKAGE",
 		Short: "Validate the given package's manifest and structure.",
+		Long:  validateDescription,
 		Args:  cobra.ExactArgs(1),
-		RunE: func(_ *cobra.Command, args []string) error {
-			return _package.Validate(args[0])
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("output") {
+				return _package.Validate(args[0])
+			}
+
+			pkg, err := _package.LoadPackage(args[0])
+			if err != nil {
+				return err
+			}
+
+			results := _package.ValidatePackage(pkg)
+
+			var rows []table.Row
+			for _, r := range results {
+				rows = append(rows, table.Row{r.Level, r.Path, r.Message})
+			}
+
+			out, err := pkgcmd.OutputRows(results, table.Row{"LEVEL", "PATH", "MESSAGE"}, rows, output)
+			if err != nil {
+				return err
+			}
+			fmt.Println(out)
+
+			if err = results.Err(); err != nil {
+				return fmt.Errorf("package is not valid")
+			}
+
+			return nil
 		},
 	}
 
+	cmd.Flags().VarP(&output, "output", "o", "Output format. One of: table|json|yaml")
+
 	return cmd
 }
@@ -0,0 +1,38 @@
+package cmd_package
+
+import (
+	_package "github.com/rancherlabs/corral/pkg/package"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const verifyDescription = `
+Verify a package in an OCI registry is signed by at least one of the given public keys.
+
+Examples:
+corral package verify --key corral.key.pub ghcr.io/rancher/my_pkg:latest
+`
+
+func NewCommandVerify() *cobra.Command {
+	var keys []string
+
+	cmd := &cobra.Command{
+		Use:   "verify REFERENCE",
+		Short: "Verify the signature of a package in an OCI registry.",
+		Long:  verifyDescription,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if err := _package.VerifyPackage(args[0], keys...); err != nil {
+				return err
+			}
+
+			logrus.Info("package signature is valid")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&keys, "key", "k", nil, "Path to a trusted public key.")
+	_ = cmd.MarkFlagRequired("key")
+
+	return cmd
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	_package "github.com/rancherlabs/corral/pkg/package"
+	"github.com/rancherlabs/corral/pkg/vars"
+	"golang.org/x/term"
+)
+
+// canPrompt returns true if stdin is a terminal.
+func canPrompt() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// promptVars prompts for each required variable that is not set.  Values are validated against the variable's schema
+// and prompted for again until they are valid.  Sensitive values are not echoed.
+func promptVars(pkg _package.Package, vs vars.VarSet) (vars.VarSet, error) {
+	missing := pkg.MissingVars(vs)
+	if len(missing) == 0 {
+		return nil, nil
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	answers := vars.VarSet{}
+
+	_, _ = fmt.Fprintf(os.Stderr, "%s requires the following variables: %s\n", pkg.Name, strings.Join(missing, ", "))
+	for _, k := range missing {
+		schema := pkg.VariableSchemas[k]
+
+		if desc := strings.TrimSpace(schema.Description); desc != "" {
+			_, _ = fmt.Fprintf(os.Stderr, "\n%s\n", desc)
+		}
+
+		message := k
+		if len(schema.Types) > 0 {
+			message += " (" + strings.Join(schema.Types, "|") + ")"
+		}
+		message += ": "
+
+		for {
+			raw, err := readVar(stdin, message, schema.Sensitive)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read [%s]: %w", k, err)
+			}
+			if raw == "" {
+				continue
+			}
+
+			v, err := schema.Parse(raw)
+			if err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "invalid value: %s\n", err)
+				continue
+			}
+
+			answers[k] = v
+			break
+		}
+	}
+
+	return answers, nil
+}
+
+// readVar reads a line from stdin, sensitive values are read without echoing them.
+func readVar(stdin *bufio.Reader, message string, sensitive bool) (string, error) {
+	_, _ = fmt.Fprint(os.Stderr, message)
+
+	if sensitive {
+		buf, err := term.ReadPassword(int(os.Stdin.Fd()))
+		_, _ = fmt.Fprintln(os.Stderr)
+		return string(buf), err
+	}
+
+	line, err := stdin.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
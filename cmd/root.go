@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"github.com/rancherlabs/corral/cmd/cache"
 	"github.com/rancherlabs/corral/cmd/config"
 
 	cmdpackage "github.com/rancherlabs/corral/cmd/package"
@@ -43,6 +44,7 @@ func Execute() {
 		NewCommandList(),
 		NewCommandVars(),
 		NewCommandCreate(),
+		cache.NewCommandCache(),
 		cmdpackage.NewCommandPackage())
 
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Enable verbose logging")
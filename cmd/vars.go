@@ -3,12 +3,16 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"sort"
 
+	"github.com/jedib0t/go-pretty/v6/table"
 	pkgcmd "github.com/rancherlabs/corral/pkg/cmd"
 	"github.com/rancherlabs/corral/pkg/config"
 	"github.com/rancherlabs/corral/pkg/corral"
 	_package "github.com/rancherlabs/corral/pkg/package"
+	"github.com/rancherlabs/corral/pkg/vars"
 	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
 )
 
 const varsDescription = `
@@ -21,6 +25,8 @@ corral vars k3s
 corral vars k3s kube_api_host node_token
 corral vars k3s kubeconfig | base64 --decode > ~/.kube/config
 corral vars k3s -a
+corral vars k3s --origin
+corral vars k3s kube_api_host --origin
 `
 
 func NewCommandVars() *cobra.Command {
@@ -35,6 +41,7 @@ func NewCommandVars() *cobra.Command {
 	cmd.Flags().Bool("sensitive", false, "Sensitive values will be displayed if this flag is true.")
 	cmd.Flags().VarP(&output, "output", "o", "Output format. One of: table|json|yaml")
 	cmd.Flags().BoolP("all", "a", false, "All values will be displayed if this flag is true.")
+	cmd.Flags().Bool("origin", false, "Show where each value came from: a default, global, profile, lock, file, env, flag or prompt value, a terraform output, a node's corral_set or corral itself.")
 
 	return cmd
 }
@@ -47,8 +54,15 @@ func listVars(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	origin, _ := cmd.Flags().GetBool("origin")
+
 	// if only one output is requested return the raw value
 	if len(args) == 2 {
+		if origin {
+			_, _ = os.Stdout.WriteString(originString(c.VarOrigins[args[1]]) + "\n")
+			return nil
+		}
+
 		_, _ = os.Stdout.WriteString(fmt.Sprintf("%v\n", c.Vars[args[1]]))
 		return nil
 	}
@@ -68,6 +82,15 @@ func listVars(cmd *cobra.Command, args []string) error {
 		vs = pkg.FilterSensitiveVars(vs)
 	}
 
+	if origin {
+		out, err := varOrigins(vs, c.VarOrigins, output)
+		if err != nil {
+			return err
+		}
+		fmt.Println(out)
+		return nil
+	}
+
 	out, err := pkgcmd.Output(vs, output, pkgcmd.OutputOptions{
 		Key:   "NAME",
 		Value: "VALUE",
@@ -78,3 +101,36 @@ func listVars(cmd *cobra.Command, args []string) error {
 	fmt.Println(out)
 	return nil
 }
+
+type varOrigin struct {
+	Value  any          `json:"value" yaml:"value"`
+	Origin *vars.Origin `json:"origin,omitempty" yaml:"origin,omitempty"`
+}
+
+// varOrigins renders the variables with their origins sorted by name.
+func varOrigins(vs vars.VarSet, origins vars.Origins, output pkgcmd.OutputFormat) (string, error) {
+	keys := maps.Keys(vs)
+	sort.Strings(keys)
+
+	values := map[string]varOrigin{}
+	var rows []table.Row
+	for _, k := range keys {
+		v := varOrigin{Value: vs[k]}
+		if o, ok := origins[k]; ok {
+			v.Origin = &o
+		}
+		values[k] = v
+		rows = append(rows, table.Row{k, vs[k], originString(origins[k])})
+	}
+
+	return pkgcmd.OutputRows(values, table.Row{"NAME", "VALUE", "ORIGIN"}, rows, output)
+}
+
+// originString formats the origin, corrals created before origins were recorded have none.
+func originString(o vars.Origin) string {
+	if o.Kind == "" {
+		return "unknown"
+	}
+
+	return o.String()
+}
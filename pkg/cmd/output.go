@@ -69,3 +69,28 @@ func Output[K comparable, V any](out map[K]V, output OutputFormat, opts OutputOp
 	}
 	return "", ErrUnknownOutputFormat
 }
+
+// OutputRows renders rows as a table with the given header or marshals v for the json and yaml formats.
+func OutputRows(v any, header table.Row, rows []table.Row, output OutputFormat) (string, error) {
+	switch output {
+	case OutputFormatTable:
+		tbl := table.NewWriter()
+		tbl.AppendHeader(header)
+		tbl.AppendSeparator()
+		tbl.AppendRows(rows)
+		return tbl.Render(), nil
+	case OutputFormatJSON:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	case OutputFormatYAML:
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(data[:len(data)-1]), nil // remove trailing newline
+	}
+	return "", ErrUnknownOutputFormat
+}
@@ -24,6 +24,35 @@ type Config struct {
 	Version string `yaml:"version"`
 
 	Vars map[string]any `yaml:"vars"`
+
+	// Profiles are named sets of variables applied on top of the global variables, e.g. per cloud region.
+	Profiles map[string]map[string]any `yaml:"profiles,omitempty"`
+
+	SignaturePolicy SignaturePolicy `yaml:"signature_policy,omitempty"`
+
+	// Offline loads remote packages from the package cache without contacting the registry.
+	Offline bool `yaml:"offline,omitempty"`
+
+	// Registries are connection settings keyed by registry host, e.g. localhost:5000.
+	Registries map[string]RegistryConfig `yaml:"registries,omitempty"`
+}
+
+// RegistryConfig controls how corral connects to an OCI registry.
+type RegistryConfig struct {
+	// PlainHTTP connects to the registry over http instead of https.
+	PlainHTTP bool `yaml:"plain_http,omitempty"`
+	// CAFile is a path to PEM encoded certificates trusted in addition to the system certificates.
+	CAFile string `yaml:"ca_file,omitempty"`
+	// Insecure skips verifying the registry's certificate.
+	Insecure bool `yaml:"insecure,omitempty"`
+}
+
+// SignaturePolicy controls which package signatures are trusted when loading remote packages.
+type SignaturePolicy struct {
+	// RequireSignatures refuses any package that is not signed by a trusted key.
+	RequireSignatures bool `yaml:"require_signatures,omitempty"`
+	// TrustedKeys are paths to PEM encoded public keys.
+	TrustedKeys []string `yaml:"trusted_keys,omitempty"`
 }
 
 func MustLoad() Config {
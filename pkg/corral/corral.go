@@ -25,6 +25,11 @@ type Corral struct {
 	RootPath string `yaml:"rootPath"`
 	Source   string `yaml:"source"`
 
+	// Reference is the package reference the corral was created from and Digest the digest it resolved to.
+	Reference        string `yaml:"reference,omitempty"`
+	Digest           string `yaml:"digest,omitempty"`
+	TerraformVersion string `yaml:"terraform_version,omitempty"`
+
 	Name       string `yaml:"name"`
 	Status     Status `yaml:"status" json:"status,omitempty"`
 	PublicKey  string `yaml:"public_key"`
@@ -32,6 +37,9 @@ type Corral struct {
 
 	NodePools map[string][]Node `yaml:"node_pools" json:"node_pools,omitempty"`
 	Vars      vars.VarSet       `yaml:"vars" json:"vars,omitempty"`
+
+	// VarOrigins records where each variable's value came from.
+	VarOrigins vars.Origins `yaml:"var_origins,omitempty" json:"var_origins,omitempty"`
 }
 
 func Load(path string) (*Corral, error) {
@@ -43,6 +51,14 @@ func Load(path string) (*Corral, error) {
 	return &c, yaml.Unmarshal(b, &c)
 }
 
+func (c *Corral) terraformVersion() string {
+	if c.TerraformVersion == "" {
+		return version.TerraformVersion
+	}
+
+	return c.TerraformVersion
+}
+
 func (c *Corral) TerraformPath(name string) string {
 	return filepath.Join(c.RootPath, "terraform", name)
 }
@@ -81,6 +97,19 @@ func (c *Corral) Delete() error {
 	return nil
 }
 
+// SetVar sets the variable and records its origin.
+func (c *Corral) SetVar(k string, v any, origin vars.Origin) {
+	if c.Vars == nil {
+		c.Vars = vars.VarSet{}
+	}
+	if c.VarOrigins == nil {
+		c.VarOrigins = vars.Origins{}
+	}
+
+	c.Vars[k] = v
+	c.VarOrigins[k] = origin
+}
+
 func (c *Corral) SetStatus(status Status) {
 	c.Status = status
 	err := c.Save()
@@ -94,7 +123,7 @@ func (c *Corral) ApplyModule(src, name string) error {
 		return err
 	}
 
-	tf, err := config.NewTerraform(c.TerraformPath(name), version.TerraformVersion)
+	tf, err := config.NewTerraform(c.TerraformPath(name), c.terraformVersion())
 	if err != nil {
 		return errors.Wrap(err, "failed to initialize terraform")
 	}
@@ -132,6 +161,7 @@ func (c *Corral) ApplyModule(src, name string) error {
 		return errors.Wrap(err, "failed read terraform output")
 	}
 
+	origin := vars.Origin{Kind: vars.OriginTerraform, Source: name}
 	for k, v := range tfOutput {
 		if k == nodePoolVarName {
 			np := map[string][]Node{}
@@ -145,14 +175,14 @@ func (c *Corral) ApplyModule(src, name string) error {
 
 			var buf bytes.Buffer
 			_ = json.NewEncoder(&buf).Encode(c.NodePools)
-			c.Vars[nodePoolVarName] = vars.Escape(&buf)
+			c.SetVar(nodePoolVarName, vars.Escape(&buf), origin)
 		}
 
 		val, err := vars.FromTerraformOutputMeta(v)
 		if err != nil {
 			return errors.Wrap(err, "failed to parse variable terraform output")
 		}
-		c.Vars[k] = val
+		c.SetVar(k, val, origin)
 	}
 
 	return nil
@@ -163,7 +193,7 @@ func (c *Corral) DestroyModule(name string) error {
 		return nil
 	}
 
-	tf, err := config.NewTerraform(c.TerraformPath(name), version.TerraformVersion)
+	tf, err := config.NewTerraform(c.TerraformPath(name), c.terraformVersion())
 	if err != nil {
 		return errors.Wrap(err, "failed to initialized terraform")
 	}
@@ -0,0 +1,49 @@
+package corral
+
+import (
+	"os"
+
+	"github.com/rancherlabs/corral/pkg/vars"
+	"gopkg.in/yaml.v3"
+)
+
+// Lock pins the package, terraform version and variables used to create a corral so it can be recreated exactly.
+type Lock struct {
+	Package          string      `yaml:"package"`
+	TerraformVersion string      `yaml:"terraform_version"`
+	Vars             vars.VarSet `yaml:"vars,omitempty"`
+	// SensitiveVars are the names of the sensitive variables that were used but are not stored in the lock.
+	SensitiveVars []string `yaml:"sensitive_vars,omitempty"`
+}
+
+func LoadLock(path string) (Lock, error) {
+	var l Lock
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return l, err
+	}
+
+	return l, yaml.Unmarshal(b, &l)
+}
+
+func (l Lock) Save(path string) error {
+	b, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, b, 0o644)
+}
+
+// MissingSensitiveVars returns the sensitive variables of the lock that are not set in the var set.
+func (l Lock) MissingSensitiveVars(vs vars.VarSet) []string {
+	var missing []string
+	for _, k := range l.SensitiveVars {
+		if _, ok := vs[k]; !ok {
+			missing = append(missing, k)
+		}
+	}
+
+	return missing
+}
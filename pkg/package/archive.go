@@ -0,0 +1,256 @@
+package _package
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"oras.land/oras-go/pkg/content"
+	"oras.land/oras-go/pkg/oras"
+	"oras.land/oras-go/pkg/registry"
+	"oras.land/oras-go/pkg/target"
+)
+
+var ErrAmbiguousArchive = errors.New("archive contains more than one package")
+
+// SavePackage builds the given package and writes it to path as a tar archive of an OCI image layout.  The package is
+// stored under ref in the archive.
+func SavePackage(pkg Package, ref, path string) error {
+	memoryStore, err := buildPackage(pkg, ref)
+	if err != nil {
+		return err
+	}
+
+	return saveArchive(context.Background(), memoryStore, ref, path)
+}
+
+// SaveRemotePackage writes the package at ref and its signatures to path as a tar archive of an OCI image layout.
+func SaveRemotePackage(ref, path string) error {
+	ref, err := resolveRef(ref)
+	if err != nil {
+		return err
+	}
+
+	registryStore, err := newRegistryStore()
+	if err != nil {
+		return err
+	}
+
+	return saveArchive(context.Background(), registryStore, ref, path)
+}
+
+// ImportPackageArchive adds the packages in an archive written by SavePackage to the package cache and returns their
+// references.  Packages that are not stored under a full registry reference can only be pushed.
+func ImportPackageArchive(path string) ([]string, error) {
+	ociStore, cleanup, err := openArchiveStore(path)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var imported []string
+	for _, ref := range archiveRefs(ociStore) {
+		if _, err := registry.ParseReference(ref); err != nil {
+			logrus.Warnf("%s is not a registry reference and can only be pushed: %s", ref, err)
+			continue
+		}
+
+		if _, err = pullPackage(context.Background(), ociStore, ref, cfg.SignaturePolicy); err != nil {
+			return imported, fmt.Errorf("failed to import %s: %w", ref, err)
+		}
+
+		imported = append(imported, ref)
+	}
+
+	return imported, nil
+}
+
+// PushPackageArchive pushes the package in an archive written by SavePackage, and any signatures of it, to ref.
+func PushPackageArchive(path, ref string) error {
+	ociStore, cleanup, err := openArchiveStore(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	refs := archiveRefs(ociStore)
+	if len(refs) != 1 {
+		return fmt.Errorf("%w: %s", ErrAmbiguousArchive, strings.Join(refs, ", "))
+	}
+
+	registryStore, err := newRegistryStore()
+	if err != nil {
+		return err
+	}
+
+	return copyPackage(context.Background(), ociStore, refs[0], registryStore, ref)
+}
+
+// saveArchive copies the package at ref from the given store into an OCI image layout and writes it to path.
+func saveArchive(ctx context.Context, from target.Target, ref, path string) error {
+	dir, err := os.MkdirTemp("", "corral-oci-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	ociStore, err := content.NewOCI(dir)
+	if err != nil {
+		return err
+	}
+
+	if err = copyPackage(ctx, from, ref, ociStore, ref); err != nil {
+		return err
+	}
+
+	if err = ociStore.SaveIndex(); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err = writeTar(f, dir); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// copyPackage copies the package at fromRef to toRef along with its signatures if it has any.
+func copyPackage(ctx context.Context, from target.Target, fromRef string, to target.Target, toRef string) error {
+	logrus.Infof("copying %s to %s", fromRef, toRef)
+	desc, err := oras.Copy(ctx, from, fromRef, to, toRef)
+	if err != nil {
+		return err
+	}
+
+	fromSigRef, err := signatureRef(fromRef, desc.Digest)
+	if err != nil {
+		logrus.Debugf("%s cannot have signatures: %s", fromRef, err)
+		return nil
+	}
+
+	if _, _, err = from.Resolve(ctx, fromSigRef); err != nil {
+		logrus.Debugf("no signatures for %s: %s", fromRef, err)
+		return nil
+	}
+
+	toSigRef, err := signatureRef(toRef, desc.Digest)
+	if err != nil {
+		logrus.Warnf("signatures of %s are not copied since %s is not a registry reference", fromRef, toRef)
+		return nil
+	}
+
+	logrus.Infof("copying signatures to %s", toSigRef)
+	_, err = oras.Copy(ctx, from, fromSigRef, to, toSigRef)
+	return err
+}
+
+// openArchiveStore extracts an OCI image layout archive and returns a store for it.  cleanup removes the extracted
+// layout.
+func openArchiveStore(path string) (*content.OCI, func(), error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	dir, err := os.MkdirTemp("", "corral-oci-")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+
+	if err = extractTar(dir, f); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to extract %s: %w", path, err)
+	}
+
+	ociStore, err := content.NewOCI(dir)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	return ociStore, cleanup, nil
+}
+
+// archiveRefs returns the sorted package references in an OCI image layout, excluding signatures.
+func archiveRefs(ociStore *content.OCI) []string {
+	var refs []string
+	for ref := range ociStore.ListReferences() {
+		if !strings.HasSuffix(ref, ".sig") {
+			refs = append(refs, ref)
+		}
+	}
+
+	sort.Strings(refs)
+
+	return refs
+}
+
+// writeTar writes the files under root to w as an uncompressed tar archive.
+func writeTar(w io.Writer, root string) error {
+	tw := tar.NewWriter(w)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == root {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+
+		if err = tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
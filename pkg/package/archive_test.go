@@ -0,0 +1,74 @@
+package _package
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancherlabs/corral/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageArchive(t *testing.T) {
+	ctx := context.Background()
+	ref := "localhost:5000/rancher/valid:v1.0.0"
+	config.InitializeRootPath(t.TempDir())
+
+	src := t.TempDir()
+	manifest, err := os.ReadFile("tests/valid.yaml")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(src, "manifest.yaml"), manifest, 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "terraform", "module"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "terraform", "module", "main.tf"), []byte("{}"), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "overlay"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "overlay", "README"), []byte("overlay"), 0o600))
+
+	pkg, err := loadLocalPackage(src)
+	require.NoError(t, err)
+
+	memoryStore, err := buildPackage(pkg, ref)
+	require.NoError(t, err)
+
+	keyPath := filepath.Join(t.TempDir(), "corral.key")
+	require.NoError(t, GenerateSigningKey(keyPath))
+	key, err := LoadSigningKey(keyPath)
+	require.NoError(t, err)
+	require.NoError(t, signPackage(ctx, memoryStore, ref, key))
+
+	archive := filepath.Join(t.TempDir(), "valid.tar")
+	require.NoError(t, saveArchive(ctx, memoryStore, ref, archive))
+
+	{ // signatures are saved with the package
+		ociStore, cleanup, err := openArchiveStore(archive)
+		require.NoError(t, err)
+		defer cleanup()
+
+		assert.Equal(t, []string{ref}, archiveRefs(ociStore))
+
+		_, desc, err := ociStore.Resolve(ctx, ref)
+		require.NoError(t, err)
+		assert.NoError(t, verifyPackage(ctx, ociStore, ref, desc, config.SignaturePolicy{
+			RequireSignatures: true,
+			TrustedKeys:       []string{keyPath + ".pub"},
+		}))
+	}
+
+	refs, err := ImportPackageArchive(archive)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ref}, refs)
+
+	// imported packages can be loaded offline
+	loaded, err := LoadPackage(ref, WithOffline(true))
+	require.NoError(t, err)
+	assert.Equal(t, "valid", loaded.Name)
+
+	buf, err := os.ReadFile(filepath.Join(loaded.TerraformModulePath("module"), "main.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(buf))
+
+	buf, err = os.ReadFile(filepath.Join(loaded.OverlayPath(), "README"))
+	require.NoError(t, err)
+	assert.Equal(t, "overlay", string(buf))
+}
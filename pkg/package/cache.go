@@ -0,0 +1,357 @@
+package _package
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/rancherlabs/corral/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+// CacheEntry is an extracted package or a downloaded layer in the package cache.
+type CacheEntry struct {
+	Reference  string    `json:"reference,omitempty" yaml:"reference,omitempty"`
+	Tags       []string  `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Digest     string    `json:"digest" yaml:"digest"`
+	Size       int64     `json:"size" yaml:"size"`
+	LastUsed   time.Time `json:"last_used" yaml:"last_used"`
+	Referenced bool      `json:"referenced" yaml:"referenced"`
+
+	Path string `json:"-" yaml:"-"`
+}
+
+// Cache is the content of the package cache.
+type Cache struct {
+	Packages []CacheEntry `json:"packages" yaml:"packages"`
+	Layers   []CacheEntry `json:"layers" yaml:"layers"`
+}
+
+// Size returns the total size of all entries in the cache.
+func (c Cache) Size() (size int64) {
+	for _, e := range c.Packages {
+		size += e.Size
+	}
+	for _, e := range c.Layers {
+		size += e.Size
+	}
+
+	return
+}
+
+// PruneOptions control which unreferenced entries are removed from the cache.  If no options are set every
+// unreferenced entry is removed.
+type PruneOptions struct {
+	// OlderThan removes entries that have not been used within the given duration.
+	OlderThan time.Duration
+	// MaxSize removes the least recently used entries until the cache is smaller than the given number of bytes.
+	MaxSize int64
+}
+
+// ListCache returns the packages, sources and layers in the package cache.  Packages extracted to one of the inUse
+// paths, and the layers they were extracted from, are marked as referenced.
+func ListCache(inUse []string) (Cache, error) {
+	var cache Cache
+
+	used := map[string]bool{}
+	for _, path := range inUse {
+		used[filepath.Clean(path)] = true
+	}
+
+	packagesRoot := config.CorralRoot("cache", "packages")
+	layersRoot := config.CorralRoot("cache", "layers")
+
+	referencedLayers := map[string]bool{}
+	err := filepath.WalkDir(packagesRoot, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() && (d.Name() == cachedTagsDir || d.Name() == cachedSignaturesDir) {
+			return filepath.SkipDir
+		}
+
+		if !d.IsDir() || !isCachedPackageDir(path) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(packagesRoot, path)
+		if err != nil {
+			return err
+		}
+
+		entry := CacheEntry{
+			Reference:  filepath.ToSlash(filepath.Dir(rel)),
+			Digest:     digest.NewDigestFromEncoded(digest.SHA256, d.Name()).String(),
+			Referenced: used[path],
+			Path:       path,
+		}
+
+		for tag, d := range cachedTags(filepath.Dir(rel)) {
+			if d.String() == entry.Digest {
+				entry.Tags = append(entry.Tags, tag)
+			}
+		}
+		sort.Strings(entry.Tags)
+
+		entry.Size, entry.LastUsed, err = diskUsage(path)
+		if err != nil {
+			return err
+		}
+
+		if entry.Referenced {
+			referencedLayers[entry.Digest] = true
+			for _, layer := range cachedLayers(layersRoot, entry.Digest) {
+				referencedLayers[layer] = true
+			}
+		}
+
+		cache.Packages = append(cache.Packages, entry)
+
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return cache, err
+	}
+
+	sources, err := listCachedSources(inUse)
+	if err != nil {
+		return cache, err
+	}
+	cache.Packages = append(cache.Packages, sources...)
+
+	layers, err := os.ReadDir(layersRoot)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return cache, err
+	}
+
+	for _, layer := range layers {
+		// skip incomplete downloads and anything else that is not a blob
+		if _, err := digest.Parse(layer.Name()); err != nil || layer.IsDir() {
+			continue
+		}
+
+		info, err := layer.Info()
+		if err != nil {
+			return cache, err
+		}
+
+		cache.Layers = append(cache.Layers, CacheEntry{
+			Digest:     layer.Name(),
+			Size:       info.Size(),
+			LastUsed:   info.ModTime(),
+			Referenced: referencedLayers[layer.Name()],
+			Path:       filepath.Join(layersRoot, layer.Name()),
+		})
+	}
+
+	sort.Slice(cache.Packages, func(i, j int) bool {
+		if cache.Packages[i].Reference != cache.Packages[j].Reference {
+			return cache.Packages[i].Reference < cache.Packages[j].Reference
+		}
+		return cache.Packages[i].LastUsed.After(cache.Packages[j].LastUsed)
+	})
+	sort.Slice(cache.Layers, func(i, j int) bool {
+		return cache.Layers[i].LastUsed.After(cache.Layers[j].LastUsed)
+	})
+
+	return cache, nil
+}
+
+// PruneCache removes unreferenced entries from the package cache and returns the removed entries.
+func PruneCache(inUse []string, opts PruneOptions) ([]CacheEntry, error) {
+	cache, err := ListCache(inUse)
+	if err != nil {
+		return nil, err
+	}
+
+	return pruneCache(cache, opts, time.Now())
+}
+
+// ClearCache removes every package and layer from the package cache.
+func ClearCache() error {
+	for _, dir := range []string{"packages", "sources", "layers"} {
+		if err := os.RemoveAll(config.CorralRoot("cache", dir)); err != nil {
+			return err
+		}
+	}
+
+	return os.MkdirAll(config.CorralRoot("cache", "layers"), 0o700)
+}
+
+func pruneCache(cache Cache, opts PruneOptions, now time.Time) ([]CacheEntry, error) {
+	var candidates []CacheEntry
+	for _, entries := range [][]CacheEntry{cache.Packages, cache.Layers} {
+		for _, e := range entries {
+			if !e.Referenced {
+				candidates = append(candidates, e)
+			}
+		}
+	}
+
+	// least recently used first
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].LastUsed.Before(candidates[j].LastUsed)
+	})
+
+	size := cache.Size()
+	pruneAll := opts.OlderThan == 0 && opts.MaxSize == 0
+
+	var removed []CacheEntry
+	for _, e := range candidates {
+		expired := opts.OlderThan > 0 && now.Sub(e.LastUsed) > opts.OlderThan
+		oversized := opts.MaxSize > 0 && size > opts.MaxSize
+		if !pruneAll && !expired && !oversized {
+			continue
+		}
+
+		logrus.Debugf("removing %s from the cache", e.Path)
+		if err := os.RemoveAll(e.Path); err != nil {
+			return removed, err
+		}
+		_ = os.Remove(e.Path + ".source")
+
+		size -= e.Size
+		removed = append(removed, e)
+	}
+
+	if opts.MaxSize > 0 && size > opts.MaxSize {
+		logrus.Warnf("the cache is still larger than %d bytes because the remaining entries are in use", opts.MaxSize)
+	}
+
+	return removed, nil
+}
+
+// listCachedSources returns the git repositories, archives and composed packages in the package cache.  Sources are referenced if a
+// corral uses a package anywhere inside of them.
+func listCachedSources(inUse []string) ([]CacheEntry, error) {
+	var entries []CacheEntry
+
+	for _, kind := range []string{gitSourceKind, archiveSourceKind, composedSourceKind} {
+		root := config.CorralRoot("cache", "sources", kind)
+
+		dirs, err := os.ReadDir(root)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, dir := range dirs {
+			// skip checkouts and extractions that are in progress
+			if !dir.IsDir() || strings.Contains(dir.Name(), "-") {
+				continue
+			}
+
+			entry := CacheEntry{
+				Reference: kind,
+				Digest:    dir.Name(),
+				Path:      filepath.Join(root, dir.Name()),
+			}
+
+			if kind == archiveSourceKind {
+				entry.Digest = digest.NewDigestFromEncoded(digest.SHA256, dir.Name()).String()
+			}
+
+			if buf, err := os.ReadFile(entry.Path + ".source"); err == nil {
+				entry.Reference = string(buf)
+			}
+
+			for _, path := range inUse {
+				if isInside(entry.Path, path) {
+					entry.Referenced = true
+				}
+			}
+
+			entry.Size, entry.LastUsed, err = diskUsage(entry.Path)
+			if err != nil {
+				return nil, err
+			}
+
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// touchCacheEntry records that a cached package or layer was used.
+func touchCacheEntry(path string) {
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		logrus.Debugf("failed to update the last used time of %s: %s", path, err)
+	}
+}
+
+// isCachedPackageDir returns true if path is a package extracted into the package cache.
+func isCachedPackageDir(path string) bool {
+	if digest.NewDigestFromEncoded(digest.SHA256, filepath.Base(path)).Validate() != nil {
+		return false
+	}
+
+	_, err := os.Stat(filepath.Join(path, "manifest.yaml"))
+	return err == nil
+}
+
+// cachedLayers returns the digests of the config and layers listed in the cached image manifest with the given
+// digest.
+func cachedLayers(layersRoot, manifestDigest string) []string {
+	buf, err := os.ReadFile(filepath.Join(layersRoot, manifestDigest))
+	if err != nil {
+		return nil
+	}
+
+	var manifest v1.Manifest
+	if err = json.Unmarshal(buf, &manifest); err != nil {
+		return nil
+	}
+
+	var layers []string
+	if manifest.Config.Digest != "" {
+		layers = append(layers, manifest.Config.Digest.String())
+	}
+	for _, layer := range manifest.Layers {
+		layers = append(layers, layer.Digest.String())
+	}
+
+	return layers
+}
+
+// diskUsage returns the total size of the files under path and the modification time of path.
+func diskUsage(path string) (size int64, modTime time.Time, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	modTime = info.ModTime()
+
+	err = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	return
+}
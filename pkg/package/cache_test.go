@@ -0,0 +1,113 @@
+package _package
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/rancherlabs/corral/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCachedPackage adds an extracted package and its layers to the cache and returns the package path.
+func writeCachedPackage(t *testing.T, repository string, layers ...string) string {
+	layersRoot := config.CorralRoot("cache", "layers")
+	require.NoError(t, os.MkdirAll(layersRoot, 0o700))
+
+	var manifest v1.Manifest
+	for _, layer := range layers {
+		d := digest.FromString(layer)
+		require.NoError(t, os.WriteFile(filepath.Join(layersRoot, d.String()), []byte(layer), 0o600))
+		manifest.Layers = append(manifest.Layers, v1.Descriptor{Digest: d, Size: int64(len(layer))})
+	}
+
+	buf, err := json.Marshal(manifest)
+	require.NoError(t, err)
+
+	d := digest.FromBytes(buf)
+	require.NoError(t, os.WriteFile(filepath.Join(layersRoot, d.String()), buf, 0o600))
+
+	path := config.CorralRoot("cache", "packages", repository, d.Encoded())
+	require.NoError(t, os.MkdirAll(path, 0o700))
+	manifestYAML, err := os.ReadFile("tests/valid.yaml")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(path, "manifest.yaml"), manifestYAML, 0o600))
+
+	return path
+}
+
+func TestListCache(t *testing.T) {
+	config.InitializeRootPath(t.TempDir())
+
+	used := writeCachedPackage(t, "ghcr.io/rancher/used", "used layer")
+	unused := writeCachedPackage(t, "ghcr.io/rancher/unused", "unused layer")
+
+	cache, err := ListCache([]string{used, "/some/local/package"})
+	require.NoError(t, err)
+
+	require.Len(t, cache.Packages, 2)
+	assert.Equal(t, "ghcr.io/rancher/unused", cache.Packages[0].Reference)
+	assert.Equal(t, unused, cache.Packages[0].Path)
+	assert.False(t, cache.Packages[0].Referenced)
+	assert.Equal(t, "ghcr.io/rancher/used", cache.Packages[1].Reference)
+	assert.True(t, cache.Packages[1].Referenced)
+
+	// each package has a manifest blob and a layer
+	require.Len(t, cache.Layers, 4)
+	referenced := 0
+	for _, layer := range cache.Layers {
+		if layer.Referenced {
+			referenced++
+		}
+	}
+	assert.Equal(t, 2, referenced)
+}
+
+func TestPruneCache(t *testing.T) {
+	now := time.Now()
+	entry := func(name string, size int64, age time.Duration, referenced bool) CacheEntry {
+		path := filepath.Join(t.TempDir(), name)
+		require.NoError(t, os.WriteFile(path, nil, 0o600))
+		return CacheEntry{Digest: name, Size: size, LastUsed: now.Add(-age), Referenced: referenced, Path: path}
+	}
+
+	cache := func() Cache {
+		return Cache{
+			Packages: []CacheEntry{
+				entry("old", 10, 48*time.Hour, false),
+				entry("new", 10, time.Hour, false),
+				entry("used", 10, 72*time.Hour, true),
+			},
+			Layers: []CacheEntry{
+				entry("layer", 10, 24*time.Hour, false),
+			},
+		}
+	}
+
+	digests := func(entries []CacheEntry) (ds []string) {
+		for _, e := range entries {
+			ds = append(ds, e.Digest)
+		}
+		return
+	}
+
+	removed, err := pruneCache(cache(), PruneOptions{}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old", "layer", "new"}, digests(removed))
+
+	removed, err = pruneCache(cache(), PruneOptions{OlderThan: 36 * time.Hour}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old"}, digests(removed))
+
+	removed, err = pruneCache(cache(), PruneOptions{MaxSize: 25}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old", "layer"}, digests(removed))
+
+	_, err = os.Stat(removed[0].Path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
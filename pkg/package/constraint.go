@@ -0,0 +1,146 @@
+package _package
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/opencontainers/go-digest"
+)
+
+var ErrNoMatchingTag = errors.New("no tag matches the version constraint")
+
+// tagRegexp matches valid OCI tags, anything else after a tag or digest separator is treated as a version constraint.
+var tagRegexp = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+
+// parseConstraintRef splits references such as ghcr.io/rancher/k3s@~1.25 or ghcr.io/rancher/k3s:^1.24 into the
+// repository and version constraint.  ok is false if the reference does not contain a version constraint.
+func parseConstraintRef(ref string) (repository, constraint string, ok bool) {
+	nameStart := strings.LastIndex(ref, "/") + 1
+	name := ref[nameStart:]
+
+	sep := strings.Index(name, "@")
+	if sep < 0 {
+		sep = strings.Index(name, ":")
+	}
+	if sep < 0 {
+		return "", "", false
+	}
+
+	repository = ref[:nameStart+sep]
+	constraint = name[sep+1:]
+
+	if tagRegexp.MatchString(constraint) {
+		return "", "", false
+	}
+
+	if _, err := digest.Parse(constraint); err == nil {
+		return "", "", false
+	}
+
+	return repository, constraint, true
+}
+
+// parseConstraint parses a version constraint.  In addition to the ranges supported by semver.ParseRange, caret
+// (^1.24) and tilde (~1.25) constraints are supported and partial versions are padded with zeros.
+func parseConstraint(constraint string) (semver.Range, error) {
+	constraint = strings.TrimSpace(constraint)
+
+	switch {
+	case strings.HasPrefix(constraint, "~"):
+		lower, err := semver.ParseTolerant(constraint[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		upper := semver.Version{Major: lower.Major, Minor: lower.Minor + 1}
+		if strings.Count(constraint, ".") == 0 {
+			upper = semver.Version{Major: lower.Major + 1}
+		}
+
+		return semver.ParseRange(fmt.Sprintf(">=%s <%s", lower, upper))
+	case strings.HasPrefix(constraint, "^"):
+		lower, err := semver.ParseTolerant(constraint[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		var upper semver.Version
+		switch {
+		case lower.Major > 0:
+			upper = semver.Version{Major: lower.Major + 1}
+		case lower.Minor > 0:
+			upper = semver.Version{Minor: lower.Minor + 1}
+		default:
+			upper = semver.Version{Patch: lower.Patch + 1}
+		}
+
+		return semver.ParseRange(fmt.Sprintf(">=%s <%s", lower, upper))
+	}
+
+	var parts []string
+	for _, part := range strings.Fields(constraint) {
+		parts = append(parts, padVersion(part))
+	}
+
+	return semver.ParseRange(strings.Join(parts, " "))
+}
+
+// padVersion adds missing minor and patch versions to a comparator, e.g. >=1.24 becomes >=1.24.0.
+func padVersion(comparator string) string {
+	v := strings.TrimLeft(comparator, "<>=!")
+	if v == "" || v == "||" || strings.ContainsAny(v, "xX*-+") {
+		return comparator
+	}
+
+	for i := strings.Count(v, "."); i < 2; i++ {
+		comparator += ".0"
+	}
+
+	return comparator
+}
+
+// selectTag returns the tag of the highest version that satisfies the constraint.  Pre-releases are only considered
+// if the constraint includes a pre-release.
+func selectTag(constraint string, tags []string) (string, error) {
+	rng, err := parseConstraint(constraint)
+	if err != nil {
+		return "", fmt.Errorf("invalid version constraint [%s]: %w", constraint, err)
+	}
+
+	allowPrerelease := strings.Contains(constraint, "-")
+
+	type candidate struct {
+		tag     string
+		version semver.Version
+	}
+
+	var candidates []candidate
+	for _, tag := range tags {
+		v, err := semver.ParseTolerant(tag)
+		if err != nil {
+			continue
+		}
+
+		if len(v.Pre) > 0 && !allowPrerelease {
+			continue
+		}
+
+		if rng(v) {
+			candidates = append(candidates, candidate{tag: tag, version: v})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("%w [%s]", ErrNoMatchingTag, constraint)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].version.GT(candidates[j].version)
+	})
+
+	return candidates[0].tag, nil
+}
@@ -0,0 +1,61 @@
+package _package
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConstraintRef(t *testing.T) {
+	tests := []struct {
+		ref        string
+		repository string
+		constraint string
+		ok         bool
+	}{
+		{ref: "ghcr.io/rancher/k3s@~1.25", repository: "ghcr.io/rancher/k3s", constraint: "~1.25", ok: true},
+		{ref: "ghcr.io/rancher/k3s:^1.24", repository: "ghcr.io/rancher/k3s", constraint: "^1.24", ok: true},
+		{ref: "localhost:5000/k3s:>=1.24 <1.26", repository: "localhost:5000/k3s", constraint: ">=1.24 <1.26", ok: true},
+		{ref: "ghcr.io/rancher/k3s:1.24.3"},
+		{ref: "ghcr.io/rancher/k3s:latest"},
+		{ref: "localhost:5000/k3s"},
+		{ref: "ghcr.io/rancher/k3s@" + testDigest},
+	}
+
+	for _, tt := range tests {
+		repository, constraint, ok := parseConstraintRef(tt.ref)
+		assert.Equal(t, tt.ok, ok, tt.ref)
+		assert.Equal(t, tt.repository, repository, tt.ref)
+		assert.Equal(t, tt.constraint, constraint, tt.ref)
+	}
+}
+
+func TestSelectTag(t *testing.T) {
+	tags := []string{"latest", "1.24.3", "v1.24.10", "1.25.0", "1.25.2", "1.26.0-rc1", "2.0.0", "0.3.1", "0.3.4", "0.4.0"}
+
+	tests := map[string]string{
+		"~1.25":         "1.25.2",
+		"~1.24.5":       "v1.24.10",
+		"~1":            "1.25.2",
+		"^1.24":         "1.25.2",
+		"^0.3":          "0.3.4",
+		">=1.24 <1.25":  "v1.24.10",
+		">=1.26.0-rc0":  "2.0.0",
+		"<1.26.0-rc2":   "1.26.0-rc1",
+		"1.24.x":        "v1.24.10",
+		">=1.0.0 <1.25": "v1.24.10",
+	}
+
+	for constraint, expected := range tests {
+		tag, err := selectTag(constraint, tags)
+		require.NoError(t, err, constraint)
+		assert.Equal(t, expected, tag, constraint)
+	}
+
+	_, err := selectTag("~3", tags)
+	assert.ErrorIs(t, err, ErrNoMatchingTag)
+
+	_, err = selectTag("~foo", tags)
+	assert.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package _package
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oras.land/oras-go/pkg/registry"
+)
+
+// CopyPackage copies the package at src, and any signatures of it, to dst.  The package is copied without changes so
+// its digest and annotations are preserved.  If dst does not include a tag or digest the reference of src is used.
+func CopyPackage(src, dst string) error {
+	src, err := resolveRef(src)
+	if err != nil {
+		return err
+	}
+
+	dst, err = destinationRef(src, dst)
+	if err != nil {
+		return err
+	}
+
+	registryStore, err := newRegistryStore()
+	if err != nil {
+		return err
+	}
+
+	return copyPackage(context.Background(), registryStore, src, registryStore, dst)
+}
+
+// MirrorPackage copies the package at ref to the same repository under target and returns the mirrored reference.
+func MirrorPackage(ref, target string) (string, error) {
+	ref, err := resolveRef(ref)
+	if err != nil {
+		return "", err
+	}
+
+	dst, err := mirrorRef(ref, target)
+	if err != nil {
+		return "", err
+	}
+
+	registryStore, err := newRegistryStore()
+	if err != nil {
+		return "", err
+	}
+
+	return dst, copyPackage(context.Background(), registryStore, ref, registryStore, dst)
+}
+
+// mirrorRef returns the reference ref is mirrored to under target, e.g. ghcr.io/rancher/k3s:v1 mirrored to
+// registry.example.com/mirror is registry.example.com/mirror/rancher/k3s:v1.
+func mirrorRef(ref, target string) (string, error) {
+	r, err := registry.ParseReference(ref)
+	if err != nil {
+		return "", err
+	}
+
+	return destinationRef(ref, strings.TrimSuffix(target, "/")+"/"+r.Repository)
+}
+
+// destinationRef returns dst with the tag or digest of src if dst does not have one.  Packages referenced by digest
+// are pushed by digest only.
+func destinationRef(src, dst string) (string, error) {
+	s, err := registry.ParseReference(src)
+	if err != nil {
+		return "", err
+	}
+
+	d, err := registry.ParseReference(dst)
+	if err != nil {
+		return "", fmt.Errorf("invalid destination: %w", err)
+	}
+
+	if d.Reference != "" {
+		if _, err = d.Digest(); err == nil {
+			return "", fmt.Errorf("invalid destination %s: packages can only be copied to a tag", dst)
+		}
+		return d.String(), nil
+	}
+
+	if _, err = s.Digest(); err == nil {
+		return d.String(), nil
+	}
+
+	d.Reference = s.Reference
+
+	return d.String(), nil
+}
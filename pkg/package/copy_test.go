@@ -0,0 +1,73 @@
+package _package
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancherlabs/corral/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"oras.land/oras-go/pkg/content"
+)
+
+func TestDestinationRef(t *testing.T) {
+	tests := []struct {
+		src, dst, expected string
+	}{
+		{src: "ghcr.io/rancher/k3s:v1", dst: "registry.example.com/k3s:v2", expected: "registry.example.com/k3s:v2"},
+		{src: "ghcr.io/rancher/k3s:v1", dst: "registry.example.com/k3s", expected: "registry.example.com/k3s:v1"},
+		{src: "ghcr.io/rancher/k3s@" + testDigest, dst: "registry.example.com/k3s", expected: "registry.example.com/k3s"},
+	}
+
+	for _, tt := range tests {
+		dst, err := destinationRef(tt.src, tt.dst)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, dst)
+	}
+
+	_, err := destinationRef("ghcr.io/rancher/k3s:v1", "registry.example.com/k3s@"+testDigest)
+	assert.Error(t, err)
+}
+
+func TestMirrorRef(t *testing.T) {
+	dst, err := mirrorRef("ghcr.io/rancher/k3s:v1", "registry.example.com/mirror/")
+	require.NoError(t, err)
+	assert.Equal(t, "registry.example.com/mirror/rancher/k3s:v1", dst)
+
+	dst, err = mirrorRef("ghcr.io/rancher/k3s@"+testDigest, "registry.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "registry.example.com/rancher/k3s", dst)
+}
+
+func TestCopyPackage(t *testing.T) {
+	ctx := context.Background()
+	src := "ghcr.io/rancher/k3s:v1"
+	dst := "registry.example.com/mirror/rancher/k3s:v1"
+
+	// use memory stores in place of registries
+	from := content.NewMemory()
+	configDescriptor, err := from.Add("", "", []byte("{}"))
+	require.NoError(t, err)
+	manifest, desc, err := content.GenerateManifest(&configDescriptor, map[string]string{PublisherAnnotation: "rancher"})
+	require.NoError(t, err)
+	require.NoError(t, from.StoreManifest(src, desc, manifest))
+
+	keyPath := filepath.Join(t.TempDir(), "corral.key")
+	require.NoError(t, GenerateSigningKey(keyPath))
+	key, err := LoadSigningKey(keyPath)
+	require.NoError(t, err)
+	require.NoError(t, signPackage(ctx, from, src, key))
+
+	to := content.NewMemory()
+	require.NoError(t, copyPackage(ctx, from, src, to, dst))
+
+	_, copied, err := to.Resolve(ctx, dst)
+	require.NoError(t, err)
+	assert.Equal(t, desc.Digest, copied.Digest)
+
+	assert.NoError(t, verifyPackage(ctx, to, dst, copied, config.SignaturePolicy{
+		RequireSignatures: true,
+		TrustedKeys:       []string{keyPath + ".pub"},
+	}))
+}
@@ -0,0 +1,241 @@
+package _package
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rancherlabs/corral/pkg/config"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	"oras.land/oras-go/pkg/registry"
+)
+
+const composedSourceKind = "composed"
+
+var (
+	ErrDependencyCycle   = errors.New("dependency cycle")
+	ErrInvalidDependency = errors.New("invalid dependency")
+)
+
+var dependencyNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// reference returns the reference the dependency is loaded from.  Relative paths are resolved against the root of the
+// depending package and the version is only appended to OCI references.
+func (d Dependency) reference(rootPath string) string {
+	if strings.HasPrefix(d.Package, "./") || strings.HasPrefix(d.Package, "../") {
+		return filepath.Join(rootPath, d.Package)
+	}
+
+	if _, ok := ociReference(d.Package); ok && d.Version != "" {
+		return d.Package + ":" + d.Version
+	}
+
+	return d.Package
+}
+
+// validate returns an error if the dependency sets a version for a package that is not an OCI repository.
+func (d Dependency) validate() error {
+	if d.Version == "" {
+		return nil
+	}
+
+	r, ok := ociReference(d.Package)
+	if !ok {
+		return fmt.Errorf("%w: version can only be set for OCI references, set the git ref or archive url in the package of %s instead", ErrInvalidDependency, d.Package)
+	}
+
+	if r.Reference != "" {
+		return fmt.Errorf("%w: %s has a tag or digest and a version, remove one of them", ErrInvalidDependency, d.Package)
+	}
+
+	return nil
+}
+
+// ociReference parses ref if it is an OCI reference and not a git repository, archive or local path.
+func ociReference(ref string) (registry.Reference, bool) {
+	if _, ok := parseSourceRef(ref); ok {
+		return registry.Reference{}, false
+	}
+
+	if filepath.IsAbs(ref) || strings.HasPrefix(ref, "./") || strings.HasPrefix(ref, "../") {
+		return registry.Reference{}, false
+	}
+
+	r, err := registry.ParseReference(ref)
+	return r, err == nil
+}
+
+// composeDependencies loads the dependencies of pkg and merges them with pkg the same way MergePackages does.  The
+// terraform modules of each package are namespaced by the name of the package, overlays are copied in order so
+// files from pkg take precedence, and variables with the same name are shared.  Variables that dependencies define
+// differently are an error unless pkg defines them as well.  The composed package is written to
+// the package cache and keeps the reference and digest of pkg.
+func composeDependencies(pkg Package, o loadOptions, parents []string) (Package, error) {
+	pinned := pkg.PinnedReference()
+	for _, parent := range parents {
+		if parent == pinned {
+			return pkg, fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(append(parents, pinned), " -> "))
+		}
+	}
+	parents = append(parents, pinned)
+
+	// modules are namespaced by package name so it must be usable as a path
+	if !dependencyNameRegexp.MatchString(pkg.Name) {
+		return pkg, fmt.Errorf("%w: packages with dependencies must have a name containing only letters, digits, '_', '.' and '-'", ErrInvalidDependency)
+	}
+
+	h := sha256.New()
+	_, _ = fmt.Fprintln(h, pinned)
+
+	// packages without a digest may change between loads so they are composed again every time
+	immutable := pkg.Digest != ""
+
+	names := map[string]string{pkg.Name: pinned}
+	deps := make([]Package, 0, len(pkg.Dependencies)+1)
+	for _, d := range pkg.Dependencies {
+		if err := d.validate(); err != nil {
+			return pkg, err
+		}
+
+		ref := d.reference(pkg.RootPath)
+
+		logrus.Infof("loading dependency %s of %s", ref, pkg.Name)
+		dep, err := loadPackage(ref, o, parents)
+		if err != nil {
+			return pkg, fmt.Errorf("failed to load dependency %s: %w", ref, err)
+		}
+
+		if d.Name != "" {
+			dep.Name = d.Name
+		}
+
+		if !dependencyNameRegexp.MatchString(dep.Name) {
+			return pkg, fmt.Errorf("%w: %s has an invalid name [%s], set a name for the dependency", ErrInvalidDependency, ref, dep.Name)
+		}
+
+		if other, ok := names[dep.Name]; ok {
+			return pkg, fmt.Errorf("%w: %s and %s are both named [%s], set a different name for one of them", ErrInvalidDependency, other, ref, dep.Name)
+		}
+		names[dep.Name] = ref
+
+		immutable = immutable && dep.Digest != ""
+		_, _ = fmt.Fprintln(h, dep.Name, dep.PinnedReference())
+
+		deps = append(deps, dep)
+	}
+
+	cacheRoot := config.CorralRoot("cache", "sources", composedSourceKind)
+	dest := filepath.Join(cacheRoot, hex.EncodeToString(h.Sum(nil)))
+
+	if _, err := os.Stat(dest); err != nil || !immutable {
+		if err = writeComposedPackage(cacheRoot, dest, pkg, deps); err != nil {
+			return pkg, fmt.Errorf("failed to compose dependencies of %s: %w", pkg.Name, err)
+		}
+		writeSourceRecord(dest, pinned)
+	}
+
+	touchCacheEntry(dest)
+
+	composed, err := loadLocalPackage(dest)
+	composed.Reference = pkg.Reference
+	composed.Digest = pkg.Digest
+
+	return composed, err
+}
+
+// dependencyVariableConflicts returns the variables that more than one dependency defines differently and pkg does not
+// define.
+func dependencyVariableConflicts(pkg Package, deps []Package) ([]string, error) {
+	definitions := map[string]any{}
+	sources := map[string]string{}
+
+	var conflicts []string
+	for _, dep := range deps {
+		buf, err := os.ReadFile(filepath.Join(dep.RootPath, "manifest.yaml"))
+		if err != nil {
+			return nil, err
+		}
+
+		yml := struct {
+			VariableSchemas map[string]any `yaml:"variables"`
+		}{}
+		if err = yaml.Unmarshal(buf, &yml); err != nil {
+			return nil, err
+		}
+
+		for k, v := range yml.VariableSchemas {
+			if existing, ok := definitions[k]; ok && !reflect.DeepEqual(existing, v) {
+				if _, ok := pkg.VariableSchemas[k]; !ok {
+					conflicts = append(conflicts, fmt.Sprintf("variable [%s] is defined differently by %s, %s", k, sources[k], dep.Name))
+				}
+			}
+			definitions[k] = v
+			sources[k] = dep.Name
+		}
+	}
+	sort.Strings(conflicts)
+
+	return conflicts, nil
+}
+
+// writeComposedPackage merges the dependencies and pkg into dest.
+func writeComposedPackage(cacheRoot, dest string, pkg Package, deps []Package) error {
+	if err := os.MkdirAll(cacheRoot, 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp(cacheRoot, "compose-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	manifest, conflicts, err := MergePackages(tmp, pkg.Description, append(deps, pkg))
+	if err != nil {
+		return err
+	}
+
+	// packages are expected to override their dependencies, but variables that two dependencies define differently
+	// must be defined by the package to choose one
+	unresolved, err := dependencyVariableConflicts(pkg, deps)
+	if err != nil {
+		return err
+	}
+	if len(unresolved) > 0 {
+		return fmt.Errorf("%w: %s, define the variable in %s to choose one", ErrInvalidDependency, strings.Join(unresolved, "; "), pkg.Name)
+	}
+
+	for _, c := range conflicts {
+		logrus.Debug(c)
+	}
+
+	manifest.Name = pkg.Name
+	manifest.Annotations = pkg.Annotations
+
+	buf, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	if err = ValidateManifest(buf); err != nil {
+		return fmt.Errorf("composed package is not a valid package: %w", err)
+	}
+
+	if err = os.WriteFile(filepath.Join(tmp, "manifest.yaml"), buf, 0o600); err != nil {
+		return err
+	}
+
+	if err = os.RemoveAll(dest); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, dest)
+}
@@ -0,0 +1,240 @@
+package _package
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancherlabs/corral/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComposeDependencies(t *testing.T) {
+	config.InitializeRootPath(t.TempDir())
+	root := t.TempDir()
+
+	writeTestPackage(t, filepath.Join(root, "docker-nodes"), `
+name: docker-nodes
+description: nodes with docker installed
+commands:
+  - module: nodes
+  - command: /opt/corral/install-docker.sh
+    node_pools: [node]
+variables:
+  node_count:
+    type: integer
+    default: 1
+  kubeconfig:
+    type: string
+    readOnly: true
+`, map[string]string{
+		"terraform/nodes/main.tf":              "nodes",
+		"overlay/opt/corral/install-docker.sh": "docker",
+		"overlay/opt/corral/motd":              "docker-nodes",
+	})
+
+	writeTestPackage(t, filepath.Join(root, "registry"), `
+name: registry
+description: a registry
+dependencies:
+  - name: base
+    package: ../docker-nodes
+commands:
+  - module: dns
+  - command: /opt/corral/install-registry.sh
+    node_pools: [node]
+variables:
+  node_count:
+    type: integer
+    default: 3
+  registry_host:
+    type: string
+    readOnly: true
+`, map[string]string{
+		"terraform/dns/main.tf":                  "dns",
+		"overlay/opt/corral/install-registry.sh": "registry",
+		"overlay/opt/corral/motd":                "registry",
+	})
+
+	pkg, err := LoadPackage(filepath.Join(root, "registry"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "registry", pkg.Name)
+	assert.Equal(t, "a registry", pkg.Description)
+	assert.Empty(t, pkg.Dependencies)
+	assert.Equal(t, []Command{
+		{Module: "base/nodes"},
+		{Command: "/opt/corral/install-docker.sh", NodePoolNames: []string{"node"}},
+		{Module: "registry/dns"},
+		{Command: "/opt/corral/install-registry.sh", NodePoolNames: []string{"node"}},
+	}, pkg.Commands)
+
+	for _, cmd := range pkg.Commands {
+		if cmd.Module != "" {
+			assert.DirExists(t, pkg.TerraformModulePath(cmd.Module))
+		}
+	}
+
+	assert.FileExists(t, filepath.Join(pkg.OverlayPath(), "opt", "corral", "install-docker.sh"))
+	assert.FileExists(t, filepath.Join(pkg.OverlayPath(), "opt", "corral", "install-registry.sh"))
+
+	// the depending package overrides files and variables of its dependencies
+	motd, err := os.ReadFile(filepath.Join(pkg.OverlayPath(), "opt", "corral", "motd"))
+	require.NoError(t, err)
+	assert.Equal(t, "registry", string(motd))
+
+	assert.Contains(t, pkg.VariableSchemas, "kubeconfig")
+	assert.Contains(t, pkg.VariableSchemas, "registry_host")
+	assert.Equal(t, 3, pkg.VariableSchemas["node_count"].Default)
+
+	// composed packages are listed in the cache
+	cache, err := ListCache([]string{pkg.RootPath})
+	require.NoError(t, err)
+	require.Len(t, cache.Packages, 1)
+	assert.Equal(t, filepath.Join(root, "registry"), cache.Packages[0].Reference)
+	assert.True(t, cache.Packages[0].Referenced)
+
+	raw, err := LoadPackage(filepath.Join(root, "registry"), WithDependencies(false))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "registry"), raw.RootPath)
+	assert.Len(t, raw.Dependencies, 1)
+}
+
+func TestComposeDependenciesErrors(t *testing.T) {
+	config.InitializeRootPath(t.TempDir())
+	root := t.TempDir()
+
+	writeTestPackage(t, filepath.Join(root, "a"), `
+name: a
+description: a
+dependencies:
+  - package: ../b
+commands: []
+`, nil)
+	writeTestPackage(t, filepath.Join(root, "b"), `
+name: b
+description: b
+dependencies:
+  - package: ../a
+commands: []
+`, nil)
+
+	_, err := LoadPackage(filepath.Join(root, "a"))
+	assert.ErrorIs(t, err, ErrDependencyCycle)
+
+	writeTestPackage(t, filepath.Join(root, "c"), `
+name: c
+description: c
+commands: []
+`, nil)
+	writeTestPackage(t, filepath.Join(root, "duplicate"), `
+name: duplicate
+description: duplicate
+dependencies:
+  - package: ../c
+  - package: ./../c
+commands: []
+`, nil)
+
+	_, err = LoadPackage(filepath.Join(root, "duplicate"))
+	assert.ErrorIs(t, err, ErrInvalidDependency)
+
+	writeTestPackage(t, filepath.Join(root, "invalid"), `
+name: invalid
+description: invalid
+dependencies:
+  - name: ../escape
+    package: ../c
+commands: []
+`, nil)
+
+	_, err = LoadPackage(filepath.Join(root, "invalid"))
+	assert.Error(t, err)
+}
+
+func TestDependencyReference(t *testing.T) {
+	tests := []struct {
+		dep     Dependency
+		want    string
+		wantErr bool
+	}{
+		{dep: Dependency{Package: "ghcr.io/rancher/k3s", Version: "^1.2"}, want: "ghcr.io/rancher/k3s:^1.2"},
+		{dep: Dependency{Package: "ghcr.io/rancher/k3s:v1.2"}, want: "ghcr.io/rancher/k3s:v1.2"},
+		{dep: Dependency{Package: "ghcr.io/rancher/k3s:v1.2", Version: "^1.2"}, wantErr: true},
+		{dep: Dependency{Package: "../k3s"}, want: "/root/k3s"},
+		{dep: Dependency{Package: "/opt/k3s"}, want: "/opt/k3s"},
+		{dep: Dependency{Package: "/opt/k3s", Version: "1.2"}, wantErr: true},
+		{dep: Dependency{Package: "git::https://github.com/rancher/packages.git//k3s?ref=main"}, want: "git::https://github.com/rancher/packages.git//k3s?ref=main"},
+		{dep: Dependency{Package: "git::https://github.com/rancher/packages.git//k3s?ref=main", Version: "1.2"}, wantErr: true},
+		{dep: Dependency{Package: "https://example.com/k3s.tgz", Version: "1.2"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dep.Package+" "+tt.dep.Version, func(t *testing.T) {
+			err := tt.dep.validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidDependency)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, tt.dep.reference("/root/pkg"))
+		})
+	}
+}
+
+func TestComposeDependenciesVariableConflicts(t *testing.T) {
+	config.InitializeRootPath(t.TempDir())
+	root := t.TempDir()
+
+	writeTestPackage(t, filepath.Join(root, "nodes"), `
+name: nodes
+description: nodes
+commands: []
+variables:
+  node_count:
+    type: integer
+    default: 1
+`, nil)
+	writeTestPackage(t, filepath.Join(root, "cluster"), `
+name: cluster
+description: cluster
+commands: []
+variables:
+  node_count:
+    type: string
+`, nil)
+
+	writeTestPackage(t, filepath.Join(root, "conflict"), `
+name: conflict
+description: conflict
+dependencies:
+  - package: ../nodes
+  - package: ../cluster
+commands: []
+`, nil)
+
+	_, err := LoadPackage(filepath.Join(root, "conflict"))
+	assert.ErrorIs(t, err, ErrInvalidDependency)
+	assert.ErrorContains(t, err, "variable [node_count] is defined differently by nodes, cluster")
+
+	// the depending package chooses the definition
+	writeTestPackage(t, filepath.Join(root, "resolved"), `
+name: resolved
+description: resolved
+dependencies:
+  - package: ../nodes
+  - package: ../cluster
+commands: []
+variables:
+  node_count:
+    type: integer
+    default: 3
+`, nil)
+
+	pkg, err := LoadPackage(filepath.Join(root, "resolved"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"integer"}, pkg.VariableSchemas["node_count"].Types)
+	assert.Equal(t, 3, pkg.VariableSchemas["node_count"].Default)
+}
@@ -0,0 +1,333 @@
+package _package
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+	ChangeMoved   ChangeKind = "moved"
+)
+
+// Change is a difference between two packages.  Field is set for changed variables.
+type Change struct {
+	Kind  ChangeKind `json:"kind" yaml:"kind"`
+	Name  string     `json:"name" yaml:"name"`
+	Field string     `json:"field,omitempty" yaml:"field,omitempty"`
+	Old   any        `json:"old,omitempty" yaml:"old,omitempty"`
+	New   any        `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+// PackageDiff lists the differences from package A to package B.
+type PackageDiff struct {
+	A           string   `json:"a" yaml:"a"`
+	B           string   `json:"b" yaml:"b"`
+	Annotations []Change `json:"annotations" yaml:"annotations"`
+	Variables   []Change `json:"variables" yaml:"variables"`
+	Commands    []Change `json:"commands" yaml:"commands"`
+	Files       []Change `json:"files" yaml:"files"`
+	// NewRequiredVariables are required by B but were not required by A.
+	NewRequiredVariables []string `json:"new_required_variables" yaml:"new_required_variables"`
+}
+
+// Empty returns true if the packages do not differ.
+func (d PackageDiff) Empty() bool {
+	return len(d.Annotations) == 0 && len(d.Variables) == 0 && len(d.Commands) == 0 && len(d.Files) == 0
+}
+
+// DiffPackages compares the manifests, terraform modules and overlays of two packages.  Only files that would be
+// published are compared.
+func DiffPackages(a, b Package) (PackageDiff, error) {
+	d := PackageDiff{
+		A:                    a.PinnedReference(),
+		B:                    b.PinnedReference(),
+		Annotations:          diffAnnotations(a.Annotations, b.Annotations),
+		Variables:            diffVariables(a.Variables(), b.Variables()),
+		Commands:             diffCommands(a.Commands, b.Commands),
+		NewRequiredVariables: []string{},
+	}
+
+	required := map[string]bool{}
+	for _, v := range a.Variables() {
+		required[v.Name] = v.Required
+	}
+	for _, v := range b.Variables() {
+		if v.Required && !required[v.Name] {
+			d.NewRequiredVariables = append(d.NewRequiredVariables, v.Name)
+		}
+	}
+
+	filesA, err := packageFileDigests(a)
+	if err != nil {
+		return d, err
+	}
+
+	filesB, err := packageFileDigests(b)
+	if err != nil {
+		return d, err
+	}
+
+	d.Files = []Change{}
+	for _, name := range sortedKeys(mergeKeys(filesA, filesB)) {
+		oldDigest, inA := filesA[name]
+		newDigest, inB := filesB[name]
+
+		switch {
+		case !inA:
+			d.Files = append(d.Files, Change{Kind: ChangeAdded, Name: name})
+		case !inB:
+			d.Files = append(d.Files, Change{Kind: ChangeRemoved, Name: name})
+		case oldDigest != newDigest:
+			d.Files = append(d.Files, Change{Kind: ChangeChanged, Name: name})
+		}
+	}
+
+	return d, nil
+}
+
+func diffAnnotations(a, b map[string]string) []Change {
+	changes := []Change{}
+	for _, k := range sortedKeys(mergeKeys(a, b)) {
+		oldValue, inA := a[k]
+		newValue, inB := b[k]
+
+		switch {
+		case !inA:
+			changes = append(changes, Change{Kind: ChangeAdded, Name: k, New: newValue})
+		case !inB:
+			changes = append(changes, Change{Kind: ChangeRemoved, Name: k, Old: oldValue})
+		case oldValue != newValue:
+			changes = append(changes, Change{Kind: ChangeChanged, Name: k, Old: oldValue, New: newValue})
+		}
+	}
+
+	return changes
+}
+
+func diffVariables(a, b []VariableInfo) []Change {
+	varsA := map[string]VariableInfo{}
+	for _, v := range a {
+		varsA[v.Name] = v
+	}
+	varsB := map[string]VariableInfo{}
+	for _, v := range b {
+		varsB[v.Name] = v
+	}
+
+	changes := []Change{}
+	for _, name := range sortedKeys(mergeKeys(varsA, varsB)) {
+		oldVar, inA := varsA[name]
+		newVar, inB := varsB[name]
+
+		switch {
+		case !inA:
+			changes = append(changes, Change{Kind: ChangeAdded, Name: name, New: newVar})
+		case !inB:
+			changes = append(changes, Change{Kind: ChangeRemoved, Name: name, Old: oldVar})
+		default:
+			for _, f := range []struct {
+				name     string
+				old, new any
+			}{
+				{"type", oldVar.Type, newVar.Type},
+				{"default", oldVar.Default, newVar.Default},
+				{"required", oldVar.Required, newVar.Required},
+				{"read_only", oldVar.ReadOnly, newVar.ReadOnly},
+				{"sensitive", oldVar.Sensitive, newVar.Sensitive},
+				{"description", oldVar.Description, newVar.Description},
+			} {
+				if !equalValues(f.old, f.new) {
+					changes = append(changes, Change{Kind: ChangeChanged, Name: name, Field: f.name, Old: f.old, New: f.new})
+				}
+			}
+		}
+	}
+
+	return changes
+}
+
+// diffCommands compares the command flows.  Commands in both flows that are not part of their longest common
+// subsequence were moved.
+func diffCommands(a, b []Command) []Change {
+	namesA := make([]string, len(a))
+	for i, c := range a {
+		namesA[i] = commandString(c)
+	}
+	namesB := make([]string, len(b))
+	for i, c := range b {
+		namesB[i] = commandString(c)
+	}
+
+	common := longestCommonSubsequence(namesA, namesB)
+
+	// commands in b that are not part of the common subsequence
+	unmatchedB := map[string]int{}
+	for _, n := range namesB {
+		unmatchedB[n]++
+	}
+	for _, n := range common {
+		unmatchedB[n]--
+	}
+
+	changes := []Change{}
+
+	// commands only in a were removed, commands in both that are out of order were moved
+	moved := map[string]int{}
+	i := 0
+	for pos, n := range namesA {
+		if i < len(common) && common[i] == n {
+			i++
+			continue
+		}
+
+		if unmatchedB[n] > 0 {
+			unmatchedB[n]--
+			moved[n]++
+			continue
+		}
+
+		changes = append(changes, Change{Kind: ChangeRemoved, Name: n, Old: pos + 1})
+	}
+
+	i = 0
+	for pos, n := range namesB {
+		if i < len(common) && common[i] == n {
+			i++
+			continue
+		}
+
+		if moved[n] > 0 {
+			moved[n]--
+			changes = append(changes, Change{Kind: ChangeMoved, Name: n, Old: indexOf(namesA, n) + 1, New: pos + 1})
+			continue
+		}
+
+		changes = append(changes, Change{Kind: ChangeAdded, Name: n, New: pos + 1})
+	}
+
+	return changes
+}
+
+func commandString(c Command) string {
+	if c.Module != "" {
+		return "module " + c.Module
+	}
+
+	s := fmt.Sprintf("command %s on %s", c.Command, strings.Join(c.NodePoolNames, ", "))
+	if c.Parallel != nil && !*c.Parallel {
+		s += " one node at a time"
+	}
+
+	return s
+}
+
+func longestCommonSubsequence(a, b []string) []string {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	var common []string
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			common = append(common, a[i])
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return common
+}
+
+func indexOf(s []string, v string) int {
+	for i := range s {
+		if s[i] == v {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// packageFileDigests returns the sha256 of every published terraform and overlay file of the package by its slash
+// separated path relative to the package root.
+func packageFileDigests(pkg Package) (map[string]string, error) {
+	ignore, err := loadIgnoreFile(pkg.RootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	digests := map[string]string{}
+	for _, prefix := range publishedDirs(pkg) {
+		dir := filepath.Join(pkg.RootPath, prefix)
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+
+		err = walkPackageDir(dir, prefix, ignore, func(rel string, d fs.DirEntry) error {
+			if d.IsDir() {
+				return nil
+			}
+
+			f, err := os.Open(filepath.Join(dir, filepath.FromSlash(rel)))
+			if err != nil {
+				return err
+			}
+			defer func() { _ = f.Close() }()
+
+			h := sha256.New()
+			if _, err = io.Copy(h, f); err != nil {
+				return err
+			}
+
+			digests[path.Join(filepath.ToSlash(prefix), rel)] = fmt.Sprintf("%x", h.Sum(nil))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return digests, nil
+}
+
+// mergeKeys returns a set of the keys of both maps.
+func mergeKeys[V any](a, b map[string]V) map[string]bool {
+	keys := map[string]bool{}
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+
+	return keys
+}
@@ -0,0 +1,133 @@
+package _package
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffPackages(t *testing.T) {
+	root := t.TempDir()
+
+	writeTestPackage(t, filepath.Join(root, "a"), `
+name: pkg
+description: pkg
+annotations:
+  corral.cattle.io/published-by: a
+  removed: x
+commands:
+  - module: main
+  - command: /opt/a.sh
+    node_pools: [all]
+  - command: /opt/b.sh
+    node_pools: [all]
+variables:
+  count:
+    type: integer
+    default: 1
+  token:
+    type: string
+  old:
+    type: string
+    optional: true
+  host:
+    type: string
+    optional: true
+`, map[string]string{
+		"terraform/main/main.tf":    "a",
+		"overlay/opt/a.sh":          "a",
+		"overlay/opt/b.sh":          "b",
+		"overlay/opt/removed":       "",
+		"overlay/opt/state.tfstate": "a",
+		".corralignore":             "*.tfstate\n",
+	})
+
+	writeTestPackage(t, filepath.Join(root, "b"), `
+name: pkg
+description: pkg
+annotations:
+  corral.cattle.io/published-by: b
+commands:
+  - module: main
+  - command: /opt/b.sh
+    node_pools: [all]
+  - command: /opt/a.sh
+    node_pools: [all]
+  - command: /opt/c.sh
+    node_pools: [all]
+    parallel: false
+variables:
+  count:
+    type: integer
+    default: 2
+  token:
+    type: string
+  region:
+    type: string
+  host:
+    type: string
+`, map[string]string{
+		"terraform/main/main.tf":    "b",
+		"overlay/opt/a.sh":          "a",
+		"overlay/opt/b.sh":          "b",
+		"overlay/opt/c.sh":          "c",
+		"overlay/opt/state.tfstate": "b",
+		".corralignore":             "*.tfstate\n",
+	})
+
+	a, err := loadLocalPackage(filepath.Join(root, "a"))
+	require.NoError(t, err)
+	b, err := loadLocalPackage(filepath.Join(root, "b"))
+	require.NoError(t, err)
+
+	d, err := DiffPackages(a, b)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Change{
+		{Kind: ChangeChanged, Name: "corral.cattle.io/published-by", Old: "a", New: "b"},
+		{Kind: ChangeRemoved, Name: "removed", Old: "x"},
+	}, d.Annotations)
+
+	assert.Equal(t, []Change{
+		{Kind: ChangeChanged, Name: "count", Field: "default", Old: 1, New: 2},
+		{Kind: ChangeChanged, Name: "host", Field: "required", Old: false, New: true},
+		{Kind: ChangeRemoved, Name: "old", Old: VariableInfo{Name: "old", Type: "string"}},
+		{Kind: ChangeAdded, Name: "region", New: VariableInfo{Name: "region", Type: "string", Required: true}},
+	}, d.Variables)
+
+	assert.Equal(t, []Change{
+		{Kind: ChangeMoved, Name: "command /opt/a.sh on all", Old: 2, New: 3},
+		{Kind: ChangeAdded, Name: "command /opt/c.sh on all one node at a time", New: 4},
+	}, d.Commands)
+
+	assert.Equal(t, []Change{
+		{Kind: ChangeAdded, Name: "overlay/opt/c.sh"},
+		{Kind: ChangeRemoved, Name: "overlay/opt/removed"},
+		{Kind: ChangeChanged, Name: "terraform/main/main.tf"},
+	}, d.Files)
+
+	assert.Equal(t, []string{"host", "region"}, d.NewRequiredVariables)
+	assert.False(t, d.Empty())
+
+	same, err := DiffPackages(a, a)
+	require.NoError(t, err)
+	assert.True(t, same.Empty())
+	assert.Empty(t, same.NewRequiredVariables)
+}
+
+func TestDiffCommands(t *testing.T) {
+	cmd := func(name string) Command { return Command{Module: name} }
+
+	assert.Empty(t, diffCommands([]Command{cmd("a"), cmd("b")}, []Command{cmd("a"), cmd("b")}))
+
+	assert.Equal(t, []Change{
+		{Kind: ChangeRemoved, Name: "module a", Old: 1},
+		{Kind: ChangeAdded, Name: "module c", New: 2},
+	}, diffCommands([]Command{cmd("a"), cmd("b")}, []Command{cmd("b"), cmd("c")}))
+
+	assert.Equal(t, []Change{
+		{Kind: ChangeMoved, Name: "module c", Old: 3, New: 1},
+	}, diffCommands([]Command{cmd("a"), cmd("b"), cmd("c")}, []Command{cmd("c"), cmd("a"), cmd("b")}))
+}
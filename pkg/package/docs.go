@@ -0,0 +1,115 @@
+package _package
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed docs
+var docsFS embed.FS
+
+type DocsFormat string
+
+const (
+	DocsFormatMarkdown DocsFormat = "markdown"
+	DocsFormatHTML     DocsFormat = "html"
+)
+
+var ErrUnknownDocsFormat = errors.New(`must be one of "markdown" or "html"`)
+
+func (f *DocsFormat) String() string {
+	return string(*f)
+}
+
+func (f *DocsFormat) Set(v string) error {
+	switch v {
+	case "markdown", "md":
+		*f = DocsFormatMarkdown
+	case "html":
+		*f = DocsFormatHTML
+	default:
+		return ErrUnknownDocsFormat
+	}
+
+	return nil
+}
+
+func (f *DocsFormat) Type() string {
+	return ""
+}
+
+type docsData struct {
+	Package
+	Variables []VariableInfo
+	NodePools []NodePoolInfo
+}
+
+var docsFuncs = map[string]any{
+	"json": func(v any) string {
+		if v == nil {
+			return ""
+		}
+
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(buf)
+	},
+	"sortedKeys": sortedKeys[string],
+	// cell escapes a markdown table cell
+	"cell": func(s string) string {
+		s = strings.ReplaceAll(s, "|", `\|`)
+		return strings.ReplaceAll(strings.TrimSpace(s), "\n", "<br>")
+	},
+	"trim": strings.TrimSpace,
+	"inc":  func(i int) int { return i + 1 },
+	"deref": func(b *bool) bool {
+		return b != nil && *b
+	},
+	// code formats a markdown code span that may contain backticks
+	"code": func(s string) string {
+		if strings.Contains(s, "`") {
+			return "`` " + s + " ``"
+		}
+		return "`" + s + "`"
+	},
+	"check": func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return ""
+	},
+}
+
+// WritePackageDocs renders the manifest of the package as markdown or html.
+func WritePackageDocs(w io.Writer, pkg Package, format DocsFormat) error {
+	data := docsData{
+		Package:   pkg,
+		Variables: pkg.Variables(),
+		NodePools: pkg.NodePools(),
+	}
+
+	switch format {
+	case DocsFormatMarkdown:
+		t, err := texttemplate.New("package.md.tmpl").Funcs(docsFuncs).ParseFS(docsFS, "docs/package.md.tmpl")
+		if err != nil {
+			return err
+		}
+		return t.Execute(w, data)
+	case DocsFormatHTML:
+		t, err := htmltemplate.New("package.html.tmpl").Funcs(docsFuncs).ParseFS(docsFS, "docs/package.html.tmpl")
+		if err != nil {
+			return err
+		}
+		return t.Execute(w, data)
+	}
+
+	return ErrUnknownDocsFormat
+}
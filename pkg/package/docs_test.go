@@ -0,0 +1,140 @@
+package _package
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadDocsPackage(t *testing.T) Package {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "pkg")
+	writeTestPackage(t, dir, `
+name: docs
+description: >
+  Documented package.
+annotations:
+  corral.cattle.io/published-by: someone
+overlay:
+  web: web
+commands:
+  - module: main
+  - command: echo "a|b" > /tmp/out
+    node_pools: [web, db]
+    parallel: false
+variables:
+  token:
+    type: string
+    sensitive: true
+    description: |
+      An API token.
+      Needs write access.
+  count:
+    type: integer
+    default: 1
+  nickname:
+    type: string
+    optional: true
+  host:
+    type: string
+    readOnly: true
+    description: <b>host</b>
+`, map[string]string{
+		"overlay/web/index.html": "",
+		"terraform/main/main.tf": `
+output "corral_node_pools" {
+  value = {
+    web   = []
+    db    = []
+    cache = []
+  }
+}
+`,
+	})
+
+	pkg, err := loadLocalPackage(dir)
+	require.NoError(t, err)
+
+	return pkg
+}
+
+func TestManifestVariables(t *testing.T) {
+	pkg := loadDocsPackage(t)
+
+	assert.Equal(t, []VariableInfo{
+		{Name: "count", Type: "integer", Default: 1},
+		{Name: "host", Type: "string", ReadOnly: true, Description: "<b>host</b>"},
+		{Name: "nickname", Type: "string"},
+		{Name: "token", Type: "string", Required: true, Sensitive: true, Description: "An API token.\nNeeds write access."},
+	}, pkg.Variables())
+}
+
+func TestPackageNodePools(t *testing.T) {
+	pkg := loadDocsPackage(t)
+
+	assert.Equal(t, []NodePoolInfo{
+		{Name: "cache"},
+		{Name: "db", Commands: []string{`echo "a|b" > /tmp/out`}},
+		{Name: "web", Overlay: "web", Commands: []string{`echo "a|b" > /tmp/out`}},
+	}, pkg.NodePools())
+}
+
+func TestWritePackageDocsMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WritePackageDocs(&buf, loadDocsPackage(t), DocsFormatMarkdown))
+
+	assert.Equal(t, "# docs\n"+`
+Documented package.
+
+## Annotations
+
+| Annotation | Value |
+| --- | --- |
+| `+"`corral.cattle.io/published-by`"+` | someone |
+
+## Commands
+
+1. Apply terraform module `+"`main`"+`
+2. Run `+"`"+`echo "a|b" > /tmp/out`+"`"+` on node pools `+"`web`, `db`"+` one node at a time
+
+## Node Pools
+
+| Node Pool | Overlay | Commands |
+| --- | --- | --- |
+| `+"`cache`"+` | `+"`overlay`"+` |  |
+| `+"`db`"+` | `+"`overlay`"+` | `+"`"+`echo "a\|b" > /tmp/out`+"`"+` |
+| `+"`web`"+` | `+"`overlay/web`"+` | `+"`"+`echo "a\|b" > /tmp/out`+"`"+` |
+
+## Variables
+
+| Name | Type | Default | Required | Read Only | Sensitive | Description |
+| --- | --- | --- | --- | --- | --- | --- |
+| `+"`count`"+` | integer | `+"`1`"+` |  |  |  |  |
+| `+"`host`"+` | string |  |  | yes |  | <b>host</b> |
+| `+"`nickname`"+` | string |  |  |  |  |  |
+| `+"`token`"+` | string |  | yes |  | yes | An API token.<br>Needs write access. |
+`, buf.String())
+}
+
+func TestWritePackageDocsHTML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WritePackageDocs(&buf, loadDocsPackage(t), DocsFormatHTML))
+
+	out := buf.String()
+	assert.Contains(t, out, "<title>docs</title>")
+	assert.Contains(t, out, "<li>Apply terraform module <code>main</code></li>")
+	assert.Contains(t, out, "<code>echo &#34;a|b&#34; &gt; /tmp/out</code>")
+	assert.Contains(t, out, "&lt;b&gt;host&lt;/b&gt;")
+	assert.NotContains(t, out, "<b>host</b>")
+}
+
+func TestDocsFormatSet(t *testing.T) {
+	var f DocsFormat
+	require.NoError(t, f.Set("md"))
+	assert.Equal(t, DocsFormatMarkdown, f)
+	assert.ErrorIs(t, f.Set("pdf"), ErrUnknownDocsFormat)
+}
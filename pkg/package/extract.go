@@ -0,0 +1,273 @@
+package _package
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrUnsafePath    = errors.New("path escapes the destination")
+	ErrUnsafeSymlink = errors.New("symlink target escapes the destination")
+)
+
+// extractLayer decompresses the gzipped tar archive in r into dest.
+func extractLayer(dest string, r io.Reader) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = gr.Close() }()
+
+	return extractTar(dest, gr)
+}
+
+// extractTar writes the tar archive in r into dest.  Entries that would be written outside of dest are rejected,
+// symlinks are only created if they resolve inside of dest and hard links must point to an earlier entry in the
+// archive.
+func extractTar(dest string, r io.Reader) error {
+	dest, err := filepath.Abs(dest)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(dest, 0o700); err != nil {
+		return err
+	}
+
+	// resolve the destination itself in case it lives under a symlink (e.g. /tmp on macOS)
+	root, err := filepath.EvalSymlinks(dest)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break // End of archive
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := securePath(root, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = extractDir(root, target)
+		case tar.TypeReg:
+			err = extractFile(root, target, header, tr)
+		case tar.TypeSymlink:
+			err = extractSymlink(root, target, header.Linkname)
+		case tar.TypeLink:
+			err = extractHardlink(root, target, header.Linkname)
+		case tar.TypeXGlobalHeader:
+			continue
+		default:
+			logrus.Warnf("skipping unsupported archive entry [%s] of type %q", header.Name, header.Typeflag)
+		}
+		if err != nil {
+			return fmt.Errorf("extracting [%s]: %w", header.Name, err)
+		}
+	}
+
+	return verifySymlinks(root)
+}
+
+func extractDir(root, target string) error {
+	if err := ensureResolvesInside(root, target); err != nil {
+		return err
+	}
+
+	return os.MkdirAll(target, 0o700)
+}
+
+func extractFile(root, target string, header *tar.Header, r io.Reader) error {
+	if err := extractDir(root, filepath.Dir(target)); err != nil {
+		return err
+	}
+
+	// never write through an existing file, it may be a symlink
+	if err := removeIfExists(target); err != nil {
+		return err
+	}
+
+	var mode fs.FileMode = 0o600
+	if header.Mode&0o111 != 0 {
+		mode = 0o700
+	}
+
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, r)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func extractSymlink(root, target, linkname string) error {
+	if filepath.IsAbs(linkname) {
+		return ErrUnsafeSymlink
+	}
+
+	if err := extractDir(root, filepath.Dir(target)); err != nil {
+		return err
+	}
+
+	if !isInside(root, filepath.Join(filepath.Dir(target), linkname)) {
+		return ErrUnsafeSymlink
+	}
+
+	if err := removeIfExists(target); err != nil {
+		return err
+	}
+
+	return os.Symlink(linkname, target)
+}
+
+func extractHardlink(root, target, linkname string) error {
+	src, err := securePath(root, linkname)
+	if err != nil {
+		return err
+	}
+
+	if err = ensureResolvesInside(root, src); err != nil {
+		return err
+	}
+
+	if err = extractDir(root, filepath.Dir(target)); err != nil {
+		return err
+	}
+
+	if err = removeIfExists(target); err != nil {
+		return err
+	}
+
+	return os.Link(src, target)
+}
+
+// securePath returns the path name would be extracted to in root or an error if it would be outside of root.
+func securePath(root, name string) (string, error) {
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+
+	target := filepath.Join(root, name)
+	if !isInside(root, target) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+
+	return target, nil
+}
+
+// ensureResolvesInside returns an error if any existing part of path is a symlink that resolves outside of root.
+func ensureResolvesInside(root, path string) error {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return err
+	}
+
+	current := root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if part == "." || part == "" {
+			continue
+		}
+
+		current = filepath.Join(current, part)
+
+		info, err := os.Lstat(current)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&fs.ModeSymlink == 0 {
+			continue
+		}
+
+		resolved, err := filepath.EvalSymlinks(current)
+		if err != nil {
+			return err
+		}
+
+		if !isInside(root, resolved) {
+			return fmt.Errorf("%w: %s", ErrUnsafePath, path)
+		}
+	}
+
+	return nil
+}
+
+// verifySymlinks returns an error if any symlink under root resolves outside of root.  Links are validated as they are
+// extracted but a chain of links can still escape once the whole archive is on disk.
+func verifySymlinks(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+
+		resolved, err := filepath.EvalSymlinks(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			// dangling links were checked lexically when they were created
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !isInside(root, resolved) {
+			return fmt.Errorf("%w: %s", ErrUnsafeSymlink, path[len(root):])
+		}
+
+		return nil
+	})
+}
+
+func isInside(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func removeIfExists(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+
+	return os.Remove(path)
+}
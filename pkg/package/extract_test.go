@@ -0,0 +1,138 @@
+package _package
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	linkname string
+	body     string
+	mode     int64
+}
+
+func buildLayer(t *testing.T, entries ...tarEntry) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, e := range entries {
+		hdr := &tar.Header{
+			Name:     e.name,
+			Typeflag: e.typeflag,
+			Linkname: e.linkname,
+			Size:     int64(len(e.body)),
+			Mode:     e.mode,
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(e.body))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	return buf.Bytes()
+}
+
+func TestExtractLayer(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		dest := t.TempDir()
+		layer := buildLayer(t,
+			tarEntry{name: "overlay", typeflag: tar.TypeDir},
+			tarEntry{name: "overlay/app/run.sh", typeflag: tar.TypeReg, body: "#!/bin/sh", mode: 0o755},
+			tarEntry{name: "overlay/app/run", typeflag: tar.TypeSymlink, linkname: "run.sh"},
+			tarEntry{name: "overlay/app/copy.sh", typeflag: tar.TypeLink, linkname: "overlay/app/run.sh"},
+		)
+
+		require.NoError(t, extractLayer(dest, bytes.NewReader(layer)))
+
+		buf, err := os.ReadFile(filepath.Join(dest, "overlay", "app", "run"))
+		require.NoError(t, err)
+		assert.Equal(t, "#!/bin/sh", string(buf))
+
+		buf, err = os.ReadFile(filepath.Join(dest, "overlay", "app", "copy.sh"))
+		require.NoError(t, err)
+		assert.Equal(t, "#!/bin/sh", string(buf))
+
+		info, err := os.Stat(filepath.Join(dest, "overlay", "app", "run.sh"))
+		require.NoError(t, err)
+		assert.NotZero(t, info.Mode()&0o100)
+	})
+
+	tests := []struct {
+		name    string
+		entries []tarEntry
+		err     error
+	}{
+		{
+			name:    "parent traversal",
+			entries: []tarEntry{{name: "../evil", typeflag: tar.TypeReg, body: "evil"}},
+			err:     ErrUnsafePath,
+		},
+		{
+			name:    "nested traversal",
+			entries: []tarEntry{{name: "overlay/../../evil", typeflag: tar.TypeReg, body: "evil"}},
+			err:     ErrUnsafePath,
+		},
+		{
+			name:    "absolute path",
+			entries: []tarEntry{{name: "/tmp/evil", typeflag: tar.TypeReg, body: "evil"}},
+			err:     ErrUnsafePath,
+		},
+		{
+			name:    "absolute symlink",
+			entries: []tarEntry{{name: "key", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"}},
+			err:     ErrUnsafeSymlink,
+		},
+		{
+			name:    "relative symlink",
+			entries: []tarEntry{{name: "overlay/key", typeflag: tar.TypeSymlink, linkname: "../../key"}},
+			err:     ErrUnsafeSymlink,
+		},
+		{
+			name: "write through symlink",
+			entries: []tarEntry{
+				{name: "a/b/c", typeflag: tar.TypeSymlink, linkname: "../.."},
+				{name: "x", typeflag: tar.TypeSymlink, linkname: "a/b/c/../../.."},
+				{name: "x/evil", typeflag: tar.TypeReg, body: "evil"},
+			},
+			err: ErrUnsafePath,
+		},
+		{
+			name: "symlink chain",
+			entries: []tarEntry{
+				{name: "a/b/c", typeflag: tar.TypeSymlink, linkname: "../.."},
+				{name: "x", typeflag: tar.TypeSymlink, linkname: "a/b/c/../../.."},
+			},
+			err: ErrUnsafeSymlink,
+		},
+		{
+			name:    "hardlink outside",
+			entries: []tarEntry{{name: "passwd", typeflag: tar.TypeLink, linkname: "../../etc/passwd"}},
+			err:     ErrUnsafePath,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dest := filepath.Join(root, "dest")
+
+			err := extractLayer(dest, bytes.NewReader(buildLayer(t, tt.entries...)))
+			assert.ErrorIs(t, err, tt.err)
+
+			_, err = os.Stat(filepath.Join(root, "evil"))
+			assert.ErrorIs(t, err, os.ErrNotExist)
+		})
+	}
+}
@@ -3,16 +3,20 @@ package _package
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 
 	"github.com/containerd/containerd/remotes"
+	"github.com/opencontainers/go-digest"
 	v1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/rancherlabs/corral/pkg/config"
 )
 
+var ErrDigestMismatch = errors.New("content does not match digest")
+
 type CachedFetcher struct {
 	cachePath string
 	source    remotes.Fetcher
@@ -25,16 +29,22 @@ func NewCachedFetcher(source remotes.Fetcher) remotes.Fetcher {
 	}
 }
 
+// Fetch returns the content of the given descriptor from the cache, downloading it from the source if necessary.  The
+// returned reader will return ErrDigestMismatch at the end of the content if it does not match the descriptor.
 func (c *CachedFetcher) Fetch(ctx context.Context, desc v1.Descriptor) (io.ReadCloser, error) {
+	if err := desc.Digest.Validate(); err != nil {
+		return nil, err
+	}
+
 	isCached, err := c.isCached(desc)
 	if err != nil {
 		return nil, err
 	}
 
-	if !isCached {
-		if err := c.cacheFromSource(ctx, desc); err != nil {
-			return nil, err
-		}
+	if isCached {
+		touchCacheEntry(c.descriptorPath(desc))
+	} else if err := c.cacheFromSource(ctx, desc); err != nil {
+		return nil, err
 	}
 
 	f, err := os.Open(c.descriptorPath(desc))
@@ -42,7 +52,7 @@ func (c *CachedFetcher) Fetch(ctx context.Context, desc v1.Descriptor) (io.ReadC
 		return nil, err
 	}
 
-	return f, nil
+	return newVerifiedReader(f, desc), nil
 }
 
 func (c *CachedFetcher) isCached(desc v1.Descriptor) (bool, error) {
@@ -66,21 +76,56 @@ func (c *CachedFetcher) cacheFromSource(ctx context.Context, desc v1.Descriptor)
 
 	defer func() { _ = r.Close() }()
 
-	// create the cache file
-	f, err := os.Create(c.descriptorPath(desc))
+	if err = os.MkdirAll(c.cachePath, 0o700); err != nil {
+		return err
+	}
+
+	// download to a temporary file so a partial or invalid download is never treated as cached
+	f, err := os.CreateTemp(c.cachePath, "download-")
 	if err != nil {
 		return err
 	}
+	defer func() { _ = os.Remove(f.Name()) }()
 
 	// copy the downloaded layer to the cache
-	_, err = io.Copy(f, r)
+	_, err = io.Copy(f, newVerifiedReader(r, desc))
+	_ = f.Close()
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return os.Rename(f.Name(), c.descriptorPath(desc))
 }
 
 func (c *CachedFetcher) descriptorPath(desc v1.Descriptor) string {
 	return filepath.Join(c.cachePath, desc.Digest.String())
 }
+
+// verifiedReader checks the content read against the digest and size of a descriptor.
+type verifiedReader struct {
+	io.ReadCloser
+
+	desc     v1.Descriptor
+	verifier digest.Verifier
+	read     int64
+}
+
+func newVerifiedReader(r io.ReadCloser, desc v1.Descriptor) io.ReadCloser {
+	return &verifiedReader{
+		ReadCloser: r,
+		desc:       desc,
+		verifier:   desc.Digest.Verifier(),
+	}
+}
+
+func (v *verifiedReader) Read(p []byte) (int, error) {
+	n, err := v.ReadCloser.Read(p)
+	v.read += int64(n)
+	_, _ = v.verifier.Write(p[:n])
+
+	if err == io.EOF && (v.read != v.desc.Size || !v.verifier.Verified()) {
+		return n, fmt.Errorf("%w: %s", ErrDigestMismatch, v.desc.Digest)
+	}
+
+	return n, err
+}
@@ -0,0 +1,38 @@
+package _package
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifiedReader(t *testing.T) {
+	content := []byte("layer content")
+	desc := v1.Descriptor{
+		Digest: digest.FromBytes(content),
+		Size:   int64(len(content)),
+	}
+
+	{ // valid
+		buf, err := io.ReadAll(newVerifiedReader(io.NopCloser(bytes.NewReader(content)), desc))
+
+		assert.NoError(t, err)
+		assert.Equal(t, content, buf)
+	}
+
+	{ // modified
+		_, err := io.ReadAll(newVerifiedReader(io.NopCloser(bytes.NewReader([]byte("layer c0ntent"))), desc))
+
+		assert.ErrorIs(t, err, ErrDigestMismatch)
+	}
+
+	{ // truncated
+		_, err := io.ReadAll(newVerifiedReader(io.NopCloser(bytes.NewReader(content[:5])), desc))
+
+		assert.ErrorIs(t, err, ErrDigestMismatch)
+	}
+}
@@ -0,0 +1,175 @@
+package _package
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// IgnoreFile lists files in a package that are not published, using the same syntax as .gitignore.  Patterns are
+// relative to the root of the package.
+const IgnoreFile = ".corralignore"
+
+// defaultIgnorePatterns are never published.  Terraform's provider and module cache is recreated when the package is
+// used.
+var defaultIgnorePatterns = []string{".terraform/", IgnoreFile}
+
+// secretFilePatterns match files that are likely to hold terraform state or credentials.
+var secretFilePatterns = []struct {
+	pattern string
+	reason  string
+}{
+	{"*.tfstate", "terraform state"},
+	{"*.tfstate.*", "terraform state"},
+	{"*.tfvars", "terraform variables"},
+	{"*.tfvars.json", "terraform variables"},
+	{".env", "an environment file"},
+	{".netrc", "credentials"},
+	{"credentials", "credentials"},
+	{"credentials.json", "credentials"},
+	{"kubeconfig*", "a kubeconfig"},
+	{"id_rsa", "a private key"},
+	{"id_ecdsa", "a private key"},
+	{"id_ed25519", "a private key"},
+	{"*.pem", "a private key or certificate"},
+	{"*.key", "a private key"},
+	{"*.p12", "a private key"},
+	{"*.pfx", "a private key"},
+}
+
+// ignoreMatcher matches package relative paths against the ignore file of a package.
+type ignoreMatcher struct {
+	gitignore.Matcher
+}
+
+// loadIgnoreFile returns a matcher for the ignore file in the package at root.  Only the default patterns are used if
+// the package does not have an ignore file.
+func loadIgnoreFile(root string) (ignoreMatcher, error) {
+	var patterns []gitignore.Pattern
+	for _, p := range defaultIgnorePatterns {
+		patterns = append(patterns, gitignore.ParsePattern(p, nil))
+	}
+
+	f, err := os.Open(filepath.Join(root, IgnoreFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ignoreMatcher{gitignore.NewMatcher(patterns)}, nil
+	}
+	if err != nil {
+		return ignoreMatcher{}, err
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		patterns = append(patterns, gitignore.ParsePattern(line, nil))
+	}
+	if err = scanner.Err(); err != nil {
+		return ignoreMatcher{}, fmt.Errorf("failed to read %s: %w", IgnoreFile, err)
+	}
+
+	return ignoreMatcher{gitignore.NewMatcher(patterns)}, nil
+}
+
+// Ignored returns true if the file at the slash separated path relative to the package root should not be published.
+func (m ignoreMatcher) Ignored(rel string, isDir bool) bool {
+	if m.Matcher == nil {
+		return false
+	}
+
+	return m.Match(strings.Split(path.Clean(rel), "/"), isDir)
+}
+
+// walkPackageDir calls fn for each file and directory under dir that is not ignored.  The paths passed to fn are slash
+// separated and relative to dir, prefix is the path of dir relative to the package root.
+func walkPackageDir(dir, prefix string, ignore ignoreMatcher, fn func(rel string, d fs.DirEntry) error) error {
+	return fs.WalkDir(os.DirFS(dir), ".", func(rel string, d fs.DirEntry, err error) error {
+		// a missing directory results in an empty layer
+		if rel == "." {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if ignore.Ignored(path.Join(filepath.ToSlash(prefix), rel), d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		return fn(rel, d)
+	})
+}
+
+// PackageWarnings returns a warning for each file that would be published with the package and looks like terraform
+// state or a secret.  Such files can be excluded with a .corralignore file.
+func PackageWarnings(pkg Package) ([]string, error) {
+	ignore, err := loadIgnoreFile(pkg.RootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	for _, prefix := range publishedDirs(pkg) {
+		dir := filepath.Join(pkg.RootPath, prefix)
+		if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		err = walkPackageDir(dir, prefix, ignore, func(rel string, d fs.DirEntry) error {
+			if d.IsDir() {
+				return nil
+			}
+
+			if reason := secretFileReason(d.Name()); reason != "" {
+				warnings = append(warnings, fmt.Sprintf("%s looks like %s", path.Join(filepath.ToSlash(prefix), rel), reason))
+			}
+
+			return nil
+		})
+		if err != nil {
+			return warnings, err
+		}
+	}
+
+	return warnings, nil
+}
+
+// publishedDirs returns the package relative directories that are published as layers.
+func publishedDirs(pkg Package) []string {
+	var dirs []string
+
+	seen := map[string]bool{}
+	for _, cmd := range pkg.Commands {
+		if cmd.Module != "" && !seen[cmd.Module] {
+			seen[cmd.Module] = true
+			dirs = append(dirs, filepath.Join("terraform", cmd.Module))
+		}
+	}
+
+	return append(dirs, "overlay")
+}
+
+func secretFileReason(name string) string {
+	for _, p := range secretFilePatterns {
+		if ok, _ := path.Match(p.pattern, name); ok {
+			return p.reason
+		}
+	}
+
+	return ""
+}
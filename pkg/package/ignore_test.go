@@ -0,0 +1,133 @@
+package _package
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePackageFiles writes a package based on tests/valid.yaml with the given files relative to the package root.
+func writePackageFiles(t *testing.T, files ...string) Package {
+	t.Helper()
+
+	root := t.TempDir()
+	manifest, err := os.ReadFile("tests/valid.yaml")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "manifest.yaml"), manifest, 0o600))
+
+	for _, f := range files {
+		path := filepath.Join(root, f)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, []byte(f), 0o600))
+	}
+
+	pkg, err := loadLocalPackage(root)
+	require.NoError(t, err)
+
+	return pkg
+}
+
+func listFiles(t *testing.T, root string) []string {
+	t.Helper()
+
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		files = append(files, filepath.ToSlash(rel))
+		return err
+	})
+	require.NoError(t, err)
+
+	sort.Strings(files)
+
+	return files
+}
+
+func TestCompressPathIgnore(t *testing.T) {
+	pkg := writePackageFiles(t,
+		IgnoreFile,
+		"terraform/module/main.tf",
+		"terraform/module/terraform.tfstate",
+		"terraform/module/.terraform/providers/provider",
+		"overlay/README.md",
+		"overlay/README.md~",
+		"overlay/build/output",
+		"overlay/build/keep",
+	)
+	require.NoError(t, os.WriteFile(filepath.Join(pkg.RootPath, IgnoreFile), []byte(`# local state
+*.tfstate
+*~
+overlay/build/*
+!overlay/build/keep
+`), 0o600))
+
+	ignore, err := loadIgnoreFile(pkg.RootPath)
+	require.NoError(t, err)
+
+	dest := t.TempDir()
+	for _, prefix := range publishedDirs(pkg) {
+		buf, err := compressPath(prefix, filepath.Join(pkg.RootPath, prefix), ignore)
+		require.NoError(t, err)
+		require.NoError(t, extractLayer(dest, bytes.NewReader(buf)))
+	}
+
+	assert.Equal(t, []string{
+		"overlay/README.md",
+		"overlay/build/keep",
+		"terraform/module/main.tf",
+	}, listFiles(t, dest))
+}
+
+func TestTemplateIgnore(t *testing.T) {
+	pkg := writePackageFiles(t,
+		"terraform/module/main.tf",
+		"terraform/module/.terraform/providers/provider",
+		"overlay/README.md",
+		"overlay/secrets.env",
+	)
+	require.NoError(t, os.WriteFile(filepath.Join(pkg.RootPath, IgnoreFile), []byte("*.env\n"), 0o600))
+
+	dest := t.TempDir()
+	_, err := copyTerraform(dest, pkg, map[string]string{})
+	require.NoError(t, err)
+	_, err = copyOverlay(dest, pkg, map[string]string{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"overlay/README.md",
+		"terraform/" + pkg.Name + "/module/main.tf",
+	}, listFiles(t, dest))
+}
+
+func TestPackageWarnings(t *testing.T) {
+	pkg := writePackageFiles(t,
+		"terraform/module/main.tf",
+		"terraform/module/terraform.tfstate",
+		"terraform/module/terraform.tfstate.backup",
+		"terraform/module/.terraform/terraform.tfstate",
+		"terraform/unused/terraform.tfstate",
+		"overlay/root/.ssh/id_rsa",
+		"overlay/etc/ignored.key",
+		"overlay/README.md",
+	)
+	require.NoError(t, os.WriteFile(filepath.Join(pkg.RootPath, IgnoreFile), []byte("overlay/etc/\n"), 0o600))
+
+	warnings, err := PackageWarnings(pkg)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"terraform/module/terraform.tfstate looks like terraform state",
+		"terraform/module/terraform.tfstate.backup looks like terraform state",
+		"overlay/root/.ssh/id_rsa looks like a private key",
+	}, warnings)
+}
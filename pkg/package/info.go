@@ -0,0 +1,134 @@
+package _package
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// PackageInfo describes a package for corral package info.
+type PackageInfo struct {
+	Name        string            `json:"name" yaml:"name"`
+	Description string            `json:"description,omitempty" yaml:"description,omitempty"`
+	Reference   string            `json:"reference,omitempty" yaml:"reference,omitempty"`
+	Digest      string            `json:"digest,omitempty" yaml:"digest,omitempty"`
+	Publisher   string            `json:"publisher,omitempty" yaml:"publisher,omitempty"`
+	PublishedAt string            `json:"published_at,omitempty" yaml:"published_at,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Commands    []Command         `json:"commands" yaml:"commands"`
+	Overlay     map[string]string `json:"overlay,omitempty" yaml:"overlay,omitempty"`
+	NodePools   []NodePoolInfo    `json:"node_pools,omitempty" yaml:"node_pools,omitempty"`
+	Variables   []VariableInfo    `json:"variables" yaml:"variables"`
+}
+
+// Info returns the package's metadata with sorted variables and node pools.
+func (b Package) Info() PackageInfo {
+	info := PackageInfo{
+		Name:        b.Name,
+		Description: strings.TrimSpace(b.Description),
+		Reference:   b.Reference,
+		Digest:      b.Digest,
+		Publisher:   b.GetAnnotation(PublisherAnnotation),
+		PublishedAt: b.GetAnnotation(PublishTimestampAnnotation),
+		Annotations: b.Annotations,
+		Commands:    b.Commands,
+		Overlay:     b.Overlay,
+		NodePools:   b.NodePools(),
+		Variables:   b.Variables(),
+	}
+
+	if len(info.Annotations) == 0 {
+		info.Annotations = nil
+	}
+
+	return info
+}
+
+// VariableInfo describes a manifest variable.
+type VariableInfo struct {
+	Name        string `json:"name" yaml:"name"`
+	Type        string `json:"type,omitempty" yaml:"type,omitempty"`
+	Default     any    `json:"default,omitempty" yaml:"default,omitempty"`
+	Required    bool   `json:"required" yaml:"required"`
+	ReadOnly    bool   `json:"read_only" yaml:"read_only"`
+	Sensitive   bool   `json:"sensitive" yaml:"sensitive"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// NodePoolInfo describes a node pool used by a package.
+type NodePoolInfo struct {
+	Name string `json:"name" yaml:"name"`
+	// Overlay is the overlay subdirectory copied to the nodes of the pool, empty if the whole overlay is copied.
+	Overlay  string   `json:"overlay,omitempty" yaml:"overlay,omitempty"`
+	Commands []string `json:"commands,omitempty" yaml:"commands,omitempty"`
+}
+
+// Variables returns the manifest's variables sorted by name.  Variables are required if they are not optional, read
+// only or have a default.
+func (m *Manifest) Variables() []VariableInfo {
+	infos := make([]VariableInfo, 0, len(m.VariableSchemas))
+	for _, name := range sortedKeys(m.VariableSchemas) {
+		s := m.VariableSchemas[name]
+
+		info := VariableInfo{
+			Name:        name,
+			Default:     s.Default,
+			Required:    s.Required(),
+			ReadOnly:    s.ReadOnly,
+			Sensitive:   s.Sensitive,
+			Description: strings.TrimSpace(s.Description),
+		}
+		if s.Schema != nil {
+			info.Type = strings.Join(s.Types, " | ")
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos
+}
+
+// NodePools returns the node pools targeted by commands and overlays and the node pools returned by the package's
+// terraform modules if they can be determined without applying them.
+func (b Package) NodePools() []NodePoolInfo {
+	pools := map[string]*NodePoolInfo{}
+	pool := func(name string) *NodePoolInfo {
+		if pools[name] == nil {
+			pools[name] = &NodePoolInfo{Name: name}
+		}
+		return pools[name]
+	}
+
+	for _, cmd := range b.Commands {
+		if cmd.Module == "" {
+			continue
+		}
+
+		if _, err := os.Stat(b.TerraformModulePath(cmd.Module)); err != nil {
+			continue
+		}
+
+		mod, _ := loadTerraformModule(b.TerraformModulePath(cmd.Module))
+		for name := range mod.nodePools {
+			pool(name)
+		}
+	}
+
+	for name, sub := range b.Overlay {
+		pool(name).Overlay = sub
+	}
+
+	for _, cmd := range b.Commands {
+		for _, name := range cmd.NodePoolNames {
+			pool(name).Commands = append(pool(name).Commands, cmd.Command)
+		}
+	}
+
+	infos := make([]NodePoolInfo, 0, len(pools))
+	for _, p := range pools {
+		infos = append(infos, *p)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+
+	return infos
+}
@@ -0,0 +1,43 @@
+package _package
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageInfo(t *testing.T) {
+	pkg := loadDocsPackage(t)
+	pkg.Reference = "registry.example.com/docs:v1"
+	pkg.Digest = "sha256:abc"
+	pkg.Annotations[PublishTimestampAnnotation] = "2022-01-02T03:04:05Z"
+
+	info := pkg.Info()
+	assert.Equal(t, "docs", info.Name)
+	assert.Equal(t, "Documented package.", info.Description)
+	assert.Equal(t, "registry.example.com/docs:v1", info.Reference)
+	assert.Equal(t, "sha256:abc", info.Digest)
+	assert.Equal(t, "someone", info.Publisher)
+	assert.Equal(t, "2022-01-02T03:04:05Z", info.PublishedAt)
+	assert.Equal(t, map[string]string{"web": "web"}, info.Overlay)
+	assert.Len(t, info.Commands, 2)
+	assert.Len(t, info.NodePools, 3)
+
+	var names []string
+	for _, v := range info.Variables {
+		names = append(names, v.Name)
+	}
+	assert.Equal(t, []string{"count", "host", "nickname", "token"}, names)
+
+	buf, err := json.Marshal(info)
+	require.NoError(t, err)
+
+	var out map[string]any
+	require.NoError(t, json.Unmarshal(buf, &out))
+	assert.Equal(t, []any{
+		map[string]any{"module": "main"},
+		map[string]any{"command": `echo "a|b" > /tmp/out`, "node_pools": []any{"web", "db"}, "parallel": false},
+	}, out["commands"])
+}
@@ -0,0 +1,122 @@
+package _package
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// scaffoldFS holds the files of new packages.  Files under common are shared by every provider, files under a
+// provider directory are only used for that provider.  Every file is rendered with text/template.
+//
+//go:embed scaffold
+var scaffoldFS embed.FS
+
+const scaffoldCommonDir = "common"
+
+// ScaffoldProviders are the providers InitPackage can create packages for.
+var ScaffoldProviders = []string{"docker", "aws", "digitalocean", "libvirt"}
+
+var (
+	ErrUnknownProvider = errors.New("unknown provider")
+	ErrPackageExists   = errors.New("directory is not empty")
+)
+
+type scaffoldData struct {
+	Name string
+}
+
+// InitPackage creates a new package in dir that creates nodes with the given provider.  The package is named after
+// dir, declares every variable corral sets for terraform modules, returns a sample node pool and runs a script on
+// each node.
+func InitPackage(dir, provider string) error {
+	if !isScaffoldProvider(provider) {
+		return fmt.Errorf("%w [%s], must be one of %s", ErrUnknownProvider, provider, strings.Join(ScaffoldProviders, ", "))
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("%w: %s", ErrPackageExists, dir)
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := template.ParseFS(scaffoldFS, "scaffold/partials.tmpl")
+	if err != nil {
+		return err
+	}
+
+	data := scaffoldData{Name: filepath.Base(abs)}
+	for _, src := range []string{scaffoldCommonDir, provider} {
+		if err = renderScaffold(tmpl, path.Join("scaffold", src), dir, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renderScaffold renders every file under src into dest.
+func renderScaffold(tmpl *template.Template, src, dest string, data scaffoldData) error {
+	return fs.WalkDir(scaffoldFS, src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, src), "/")
+		target := filepath.Join(dest, filepath.FromSlash(rel))
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o700)
+		}
+
+		buf, err := scaffoldFS.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		t, err := tmpl.Clone()
+		if err != nil {
+			return err
+		}
+
+		if t, err = t.New(rel).Parse(string(buf)); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", rel, err)
+		}
+
+		var out bytes.Buffer
+		if err = t.Execute(&out, data); err != nil {
+			return fmt.Errorf("failed to render %s: %w", rel, err)
+		}
+
+		var mode fs.FileMode = 0o644
+		if strings.HasSuffix(rel, ".sh") {
+			mode = 0o755
+		}
+
+		return os.WriteFile(target, out.Bytes(), mode)
+	})
+}
+
+func isScaffoldProvider(provider string) bool {
+	for _, p := range ScaffoldProviders {
+		if p == provider {
+			return true
+		}
+	}
+
+	return false
+}
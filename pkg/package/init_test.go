@@ -0,0 +1,48 @@
+package _package
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancherlabs/corral/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitPackage(t *testing.T) {
+	config.InitializeRootPath(t.TempDir())
+
+	for _, provider := range ScaffoldProviders {
+		t.Run(provider, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "my-"+provider)
+
+			require.NoError(t, InitPackage(dir, provider))
+			require.NoError(t, Validate(dir))
+
+			pkg, err := LoadPackage(dir)
+			require.NoError(t, err)
+			assert.Equal(t, "my-"+provider, pkg.Name)
+
+			tf, err := os.ReadFile(filepath.Join(dir, "terraform", "main", "corral.tf"))
+			require.NoError(t, err)
+			for _, v := range []string{"corral_name", "corral_user_id", "corral_user_public_key", "corral_public_key", "corral_private_key", "corral_ssh_key_type", "corral_node_pools"} {
+				assert.Contains(t, string(tf), `variable "`+v+`"`)
+			}
+			assert.Contains(t, string(tf), `output "corral_node_pools"`)
+
+			i, err := os.Stat(filepath.Join(dir, "overlay", "opt", "corral", "install.sh"))
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o755), i.Mode().Perm())
+		})
+	}
+}
+
+func TestInitPackageErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	assert.ErrorIs(t, InitPackage(filepath.Join(dir, "pkg"), "vsphere"), ErrUnknownProvider)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "manifest.yaml"), []byte("name: existing"), 0o600))
+	assert.ErrorIs(t, InitPackage(dir, "docker"), ErrPackageExists)
+}
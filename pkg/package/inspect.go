@@ -0,0 +1,194 @@
+package _package
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/containerd/remotes"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrManifestNotFound = errors.New("package does not contain a manifest")
+
+// InspectPackage returns the package at the given reference without downloading its terraform modules or overlay.
+// Local packages and packages from git repositories or archives are loaded as usual.  Packages returned from a registry have an empty RootPath.
+func InspectPackage(ref string, opts ...LoadOption) (Package, error) {
+	if _, ok := parseSourceRef(ref); ok {
+		return LoadPackage(ref, append(opts, WithDependencies(false))...)
+	}
+
+	path, _ := filepath.Abs(ref)
+	if _, err := os.Stat(path); err == nil {
+		return loadLocalPackage(path)
+	} else if strings.HasPrefix(ref, "./") {
+		return Package{}, err
+	}
+
+	o, err := newLoadOptions(opts)
+	if err != nil {
+		return Package{}, err
+	}
+
+	if *o.offline {
+		return loadCachedPackage(ref, o.config.SignaturePolicy)
+	}
+
+	ref, err = resolveRef(ref)
+	if err != nil {
+		return Package{}, err
+	}
+
+	registryStore, err := newRegistryStore()
+	if err != nil {
+		return Package{}, err
+	}
+
+	_, desc, err := registryStore.Resolve(context.Background(), ref)
+	if err != nil {
+		return Package{}, err
+	}
+
+	err = verifyPackage(context.Background(), registryStore, ref, desc, o.config.SignaturePolicy)
+	if err != nil {
+		return Package{}, err
+	}
+
+	storeFetcher, err := registryStore.Fetcher(context.Background(), ref)
+	if err != nil {
+		return Package{}, err
+	}
+
+	fetcher := NewCachedFetcher(storeFetcher)
+
+	imageManifest, err := fetchImageManifest(fetcher, desc)
+	if err != nil {
+		return Package{}, err
+	}
+
+	buf, err := fetchPackageManifest(fetcher, imageManifest)
+	if err != nil {
+		return Package{}, err
+	}
+
+	pkg := Package{
+		Reference: ref,
+		Digest:    desc.Digest.String(),
+	}
+
+	pkg.Manifest, err = ParseManifest(buf)
+	if err != nil {
+		return pkg, err
+	}
+
+	// annotations added when publishing are stored on the image manifest
+	for k, v := range imageManifest.Annotations {
+		if _, ok := pkg.Annotations[k]; !ok {
+			pkg.Annotations[k] = v
+		}
+	}
+
+	return pkg, nil
+}
+
+// fetchPackageManifest returns the contents of manifest.yaml.  Packages store the manifest in the config, older
+// packages used the manifest as a config without the corral config media type.  Layers are only searched for the
+// manifest if the config is not a manifest.
+func fetchPackageManifest(fetcher remotes.Fetcher, imageManifest v1.Manifest) ([]byte, error) {
+	buf, err := fetchBlob(fetcher, imageManifest.Config)
+	if imageManifest.Config.MediaType == ConfigMediaType {
+		return buf, err
+	}
+
+	if err == nil {
+		if _, err = ParseManifest(buf); err == nil {
+			return buf, nil
+		}
+	}
+
+	logrus.Debugf("config of the package is not a manifest, searching the layers: %s", err)
+
+	return fetchManifestLayer(fetcher, imageManifest)
+}
+
+func fetchBlob(fetcher remotes.Fetcher, desc v1.Descriptor) ([]byte, error) {
+	r, err := fetcher.Fetch(context.Background(), desc)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+
+	return io.ReadAll(newVerifiedReader(r, desc))
+}
+
+// fetchManifestLayer returns the contents of manifest.yaml from the first layer that contains it.  The layer with the
+// manifest media type is tried first so normally only one layer is downloaded.
+func fetchManifestLayer(fetcher remotes.Fetcher, imageManifest v1.Manifest) ([]byte, error) {
+	layers := make([]v1.Descriptor, 0, len(imageManifest.Layers))
+	for _, layer := range imageManifest.Layers {
+		if layer.MediaType == ManifestLayerMediaType {
+			layers = append([]v1.Descriptor{layer}, layers...)
+		} else {
+			layers = append(layers, layer)
+		}
+	}
+
+	for _, layer := range layers {
+		buf, err := readManifestFromLayer(fetcher, layer)
+		if errors.Is(err, ErrManifestNotFound) {
+			continue
+		}
+
+		return buf, err
+	}
+
+	return nil, ErrManifestNotFound
+}
+
+func readManifestFromLayer(fetcher remotes.Fetcher, layer v1.Descriptor) ([]byte, error) {
+	r, err := fetcher.Fetch(context.Background(), layer)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest []byte
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if manifest == nil && header.Typeflag == tar.TypeReg && filepath.Clean(header.Name) == "manifest.yaml" {
+			if manifest, err = io.ReadAll(tr); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// read any trailing data so the digest is checked against the entire layer
+	if _, err = io.Copy(io.Discard, r); err != nil {
+		return nil, err
+	}
+
+	if manifest == nil {
+		return nil, ErrManifestNotFound
+	}
+
+	return manifest, nil
+}
@@ -0,0 +1,97 @@
+package _package
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/containerd/containerd/remotes"
+	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchManifestLayer(t *testing.T) {
+	blobs := map[digest.Digest][]byte{}
+	addLayer := func(layer []byte) v1.Descriptor {
+		d := digest.FromBytes(layer)
+		blobs[d] = layer
+		return v1.Descriptor{Digest: d, Size: int64(len(layer))}
+	}
+
+	fetcher := remotes.FetcherFunc(func(_ context.Context, desc v1.Descriptor) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(blobs[desc.Digest])), nil
+	})
+
+	terraform := addLayer(buildLayer(t, tarEntry{name: "terraform/module/main.tf", typeflag: tar.TypeReg, body: "{}"}))
+	manifest := addLayer(buildLayer(t,
+		tarEntry{name: "overlay", typeflag: tar.TypeDir},
+		tarEntry{name: "manifest.yaml", typeflag: tar.TypeReg, body: "name: test"},
+	))
+
+	buf, err := fetchManifestLayer(fetcher, v1.Manifest{Layers: []v1.Descriptor{terraform, manifest}})
+	require.NoError(t, err)
+	assert.Equal(t, "name: test", string(buf))
+
+	_, err = fetchManifestLayer(fetcher, v1.Manifest{Layers: []v1.Descriptor{terraform}})
+	assert.ErrorIs(t, err, ErrManifestNotFound)
+}
+
+func TestFetchPackageManifest(t *testing.T) {
+	const body = "name: test\ndescription: test\n"
+
+	blobs := map[digest.Digest][]byte{}
+	addBlob := func(mediaType string, blob []byte) v1.Descriptor {
+		d := digest.FromBytes(blob)
+		blobs[d] = blob
+		return v1.Descriptor{MediaType: mediaType, Digest: d, Size: int64(len(blob))}
+	}
+
+	var fetched []digest.Digest
+	fetcher := remotes.FetcherFunc(func(_ context.Context, desc v1.Descriptor) (io.ReadCloser, error) {
+		fetched = append(fetched, desc.Digest)
+		return io.NopCloser(bytes.NewReader(blobs[desc.Digest])), nil
+	})
+
+	layer := addBlob(v1.MediaTypeImageLayer, buildLayer(t,
+		tarEntry{name: "manifest.yaml", typeflag: tar.TypeReg, body: body},
+	))
+
+	tests := []struct {
+		name   string
+		config v1.Descriptor
+		layers bool
+	}{
+		{
+			name:   "config",
+			config: addBlob(ConfigMediaType, []byte(body)),
+		},
+		{
+			name:   "legacy config",
+			config: addBlob(v1.MediaTypeImageLayer, []byte(body)),
+		},
+		{
+			name:   "config is not a manifest",
+			config: addBlob(v1.MediaTypeImageConfig, []byte("{}")),
+			layers: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetched = nil
+
+			buf, err := fetchPackageManifest(fetcher, v1.Manifest{Config: tt.config, Layers: []v1.Descriptor{layer}})
+			require.NoError(t, err)
+			assert.Equal(t, body, string(buf))
+
+			if tt.layers {
+				assert.Contains(t, fetched, layer.Digest)
+			} else {
+				assert.NotContains(t, fetched, layer.Digest)
+			}
+		})
+	}
+}
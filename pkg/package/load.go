@@ -1,8 +1,6 @@
 package _package
 
 import (
-	"archive/tar"
-	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
@@ -14,14 +12,45 @@ import (
 	"strings"
 
 	"github.com/blang/semver"
+	"github.com/containerd/containerd/remotes"
+	"github.com/opencontainers/go-digest"
 	"github.com/rancherlabs/corral/pkg/version"
 
 	"github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/rancherlabs/corral/pkg/config"
 	"github.com/sirupsen/logrus"
+	"oras.land/oras-go/pkg/registry"
+	"oras.land/oras-go/pkg/target"
 )
 
-func LoadPackage(ref string) (Package, error) {
+// LoadPackage loads the package at ref, which may be a local directory, a git repository, an archive or an OCI
+// reference.  The dependencies of the package are composed into it unless WithDependencies(false) is given.
+func LoadPackage(ref string, opts ...LoadOption) (Package, error) {
+	o, err := newLoadOptions(opts)
+	if err != nil {
+		return Package{}, err
+	}
+
+	return loadPackage(ref, o, nil)
+}
+
+// loadPackage loads the package at ref and composes its dependencies.  parents are the pinned references of the
+// packages that depend on it.
+func loadPackage(ref string, o loadOptions, parents []string) (Package, error) {
+	pkg, err := loadPackageOnly(ref, o)
+	if err != nil || o.skipDependencies || len(pkg.Dependencies) == 0 {
+		return pkg, err
+	}
+
+	return composeDependencies(pkg, o, parents)
+}
+
+// loadPackageOnly loads the package at ref without its dependencies.
+func loadPackageOnly(ref string, o loadOptions) (Package, error) {
+	if s, ok := parseSourceRef(ref); ok {
+		return loadSourcePackage(s, *o.offline)
+	}
+
 	path, _ := filepath.Abs(ref)
 	if _, err := os.Stat(path); err == nil {
 		return loadLocalPackage(path)
@@ -29,12 +58,56 @@ func LoadPackage(ref string) (Package, error) {
 		return Package{}, err
 	}
 
-	if !strings.Contains(ref, ":") {
+	if *o.offline {
+		return loadCachedPackage(ref, o.config.SignaturePolicy)
+	}
+
+	ref, err := resolveRef(ref)
+	if err != nil {
+		return Package{}, err
+	}
+
+	return loadRemotePackage(ref, o.config.SignaturePolicy)
+}
+
+// resolveRef resolves any version constraint in the given reference to a tag and defaults to the latest tag.
+func resolveRef(ref string) (string, error) {
+	if repository, constraint, ok := parseConstraintRef(ref); ok {
+		tags, err := ListTags(repository)
+		if err != nil {
+			return "", fmt.Errorf("failed to list tags for %s: %w", repository, err)
+		}
+
+		tag, err := selectTag(constraint, tags)
+		if err != nil {
+			return "", err
+		}
+
+		logrus.Infof("Resolved %s to tag %s.", ref, tag)
+		ref = repository + ":" + tag
+	}
+
+	return normalizeRef(ref), nil
+}
+
+// normalizeRef defaults references without a tag or digest to the latest tag.
+func normalizeRef(ref string) string {
+	r, err := registry.ParseReference(ref)
+	if err != nil {
+		// let the resolver report invalid references
+		if !strings.Contains(ref, ":") {
+			logrus.Info("Defaulting to latest tag.")
+			ref += ":latest"
+		}
+		return ref
+	}
+
+	if r.Reference == "" {
 		logrus.Info("Defaulting to latest tag.")
-		ref += ":latest"
+		r.Reference = "latest"
 	}
 
-	return loadRemotePackage(ref)
+	return r.String()
 }
 
 func loadLocalPackage(src string) (pkg Package, err error) {
@@ -52,30 +125,57 @@ func loadLocalPackage(src string) (pkg Package, err error) {
 	return pkg, nil
 }
 
-func loadRemotePackage(ref string) (pkg Package, err error) {
+func loadRemotePackage(ref string, policy config.SignaturePolicy) (pkg Package, err error) {
 	registryStore, err := newRegistryStore()
 	if err != nil {
 		return
 	}
 
+	return pullPackage(context.Background(), registryStore, ref, policy)
+}
+
+// pullPackage extracts the package at ref in the given store into the package cache and loads it.
+func pullPackage(ctx context.Context, store target.Target, ref string, policy config.SignaturePolicy) (pkg Package, err error) {
 	// get the latest digest for the ref
-	_, desc, err := registryStore.Resolve(context.Background(), ref)
+	_, desc, err := store.Resolve(ctx, ref)
+	if err != nil {
+		return
+	}
+
+	// refuse packages that do not satisfy the signature policy before anything is downloaded
+	keyID, err := verifyPackageKey(ctx, store, ref, desc, policy)
 	if err != nil {
 		return
 	}
 
 	// check if this digest has already been cached
-	dest := filepath.Join(config.CorralRoot("cache", "packages"), getRefPath(ref, string(desc.Digest)))
+	cacheRoot := config.CorralRoot("cache", "packages")
+	refPath, err := getRefPath(ref, desc.Digest)
+	if err != nil {
+		return
+	}
+
+	dest := filepath.Join(cacheRoot, refPath)
+	if !isInside(cacheRoot, dest) {
+		return pkg, fmt.Errorf("%w: %s", ErrUnsafePath, ref)
+	}
+
 	_, err = os.Stat(dest)
 	if err == nil {
-		return loadLocalPackage(dest)
+		touchCacheEntry(dest)
+		recordTag(ref, desc.Digest)
+		recordVerification(ref, desc.Digest, keyID)
+		pkg, err = loadLocalPackage(dest)
+		pkg.Reference = ref
+		pkg.Digest = desc.Digest.String()
+		return
 	}
 	if !errors.Is(err, os.ErrNotExist) {
 		return
 	}
 
 	// use a cached fetcher to cache the layers
-	storeFetcher, err := registryStore.Fetcher(context.Background(), ref)
+	storeFetcher, err := store.Fetcher(ctx, ref)
 	if err != nil {
 		return
 	}
@@ -86,13 +186,7 @@ func loadRemotePackage(ref string) (pkg Package, err error) {
 	}
 
 	// fetch the image manifest
-	r, err := fetcher.Fetch(context.Background(), desc)
-	if err != nil {
-		return
-	}
-
-	var manifest v1.Manifest
-	err = json.NewDecoder(r).Decode(&manifest)
+	manifest, err := fetchImageManifest(fetcher, desc)
 	if err != nil {
 		return
 	}
@@ -113,14 +207,14 @@ func loadRemotePackage(ref string) (pkg Package, err error) {
 
 	// extract the layers to the destination
 	for _, layer := range manifest.Layers {
-		var layerReader io.ReadCloser
-		layerReader, err = fetcher.Fetch(context.Background(), layer)
-		if err != nil {
-			return
+		if !isPackageLayer(layer) {
+			logrus.Debugf("skipping layer %s with unknown media type %s", layer.Digest, layer.MediaType)
+			continue
 		}
 
-		if err = extractLayer(dest, layerReader); err != nil {
-			return
+		if err = fetchLayer(fetcher, dest, layer); err != nil {
+			_ = os.RemoveAll(dest)
+			return pkg, fmt.Errorf("failed to extract layer %s: %w", layer.Digest, err)
 		}
 	}
 
@@ -129,6 +223,11 @@ func loadRemotePackage(ref string) (pkg Package, err error) {
 		return pkg, err
 	}
 
+	pkg.Reference = ref
+	pkg.Digest = desc.Digest.String()
+	recordTag(ref, desc.Digest)
+	recordVerification(ref, desc.Digest, keyID)
+
 	if pkg.Annotations[CorralVersionAnnotation] != "" {
 		pkv, err := semver.Parse(pkg.Annotations[CorralVersionAnnotation])
 		if err != nil {
@@ -147,55 +246,59 @@ func loadRemotePackage(ref string) (pkg Package, err error) {
 	return pkg, nil
 }
 
-func extractLayer(dest string, r io.Reader) error {
-	gr, err := gzip.NewReader(r)
+// fetchImageManifest returns the OCI manifest with the given descriptor.
+func fetchImageManifest(fetcher remotes.Fetcher, desc v1.Descriptor) (manifest v1.Manifest, err error) {
+	r, err := fetcher.Fetch(context.Background(), desc)
 	if err != nil {
-		return err
+		return
 	}
+	defer func() { _ = r.Close() }()
 
-	tr := tar.NewReader(gr)
-	for {
-		header, err := tr.Next()
-		if err == io.EOF {
-			break // End of archive
-		}
+	// read the whole manifest so the digest is verified
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return
+	}
 
-		if header.Typeflag == tar.TypeDir {
-			err = os.MkdirAll(filepath.Join(dest, header.Name), 0o700)
-			if err != nil {
-				return err
-			}
-			continue
-		}
+	err = json.Unmarshal(buf, &manifest)
+	return
+}
 
-		destPath, _ := filepath.Split(header.Name)
-		err = os.MkdirAll(filepath.Join(dest, destPath), 0o700)
-		if err != nil {
-			return err
-		}
+// isPackageLayer returns true if the layer contains files of the package.
+func isPackageLayer(layer v1.Descriptor) bool {
+	switch layer.MediaType {
+	case ManifestLayerMediaType, TerraformLayerMediaType, OverlayLayerMediaType, v1.MediaTypeImageLayerGzip:
+		return true
+	}
 
-		f, err := os.Create(filepath.Join(dest, header.Name))
-		if err != nil {
-			return err
-		}
+	return false
+}
 
-		_, err = io.Copy(f, tr)
-		if err != nil {
-			_ = f.Close()
-			return err
-		}
+// fetchLayer extracts the given layer into dest and verifies the layer matches its descriptor.
+func fetchLayer(fetcher remotes.Fetcher, dest string, layer v1.Descriptor) error {
+	r, err := fetcher.Fetch(context.Background(), layer)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
 
-		_ = f.Close()
+	if err = extractLayer(dest, r); err != nil {
+		return err
 	}
 
-	return nil
+	// read any trailing data so the digest is checked against the entire layer
+	_, err = io.Copy(io.Discard, r)
+	return err
 }
 
-func getRefPath(ref, digest string) string {
-	ref = strings.Split(ref, ":")[0]
-	digest = strings.Split(digest, ":")[1]
+// getRefPath returns the path a package is cached at relative to the package cache.
+func getRefPath(ref string, d digest.Digest) (string, error) {
+	r, err := registry.ParseReference(ref)
+	if err != nil {
+		return "", err
+	}
 
-	return filepath.Join(append(strings.Split(ref, "/"), digest)...)
+	return filepath.Join(repositoryPath(r), d.Encoded()), nil
 }
 
 func migrateScriptsToOverlay(pkg Package) error {
@@ -0,0 +1,55 @@
+package _package
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDigest = "sha256:8f434346648f6b96df89dda901c5176b10a6d83961dd3c1ac88b59b2dc327aa4"
+
+func TestNormalizeRef(t *testing.T) {
+	tests := map[string]string{
+		"ghcr.io/rancher/k3s":                  "ghcr.io/rancher/k3s:latest",
+		"ghcr.io/rancher/k3s:v1":               "ghcr.io/rancher/k3s:v1",
+		"localhost:5000/k3s":                   "localhost:5000/k3s:latest",
+		"ghcr.io/rancher/k3s@" + testDigest:    "ghcr.io/rancher/k3s@" + testDigest,
+		"ghcr.io/rancher/k3s:v1@" + testDigest: "ghcr.io/rancher/k3s@" + testDigest,
+		"doesnotexist":                         "doesnotexist:latest",
+	}
+
+	for in, expected := range tests {
+		assert.Equal(t, expected, normalizeRef(in), in)
+	}
+}
+
+func TestGetRefPath(t *testing.T) {
+	d := digest.Digest(testDigest)
+
+	{ // tag
+		res, err := getRefPath("ghcr.io/rancher/k3s:latest", d)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join("ghcr.io", "rancher", "k3s", d.Encoded()), res)
+	}
+
+	{ // registry port and digest
+		res, err := getRefPath("localhost:5000/k3s@"+testDigest, d)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join("localhost:5000", "k3s", d.Encoded()), res)
+	}
+}
+
+func TestPinnedReference(t *testing.T) {
+	{ // remote
+		pkg := Package{RootPath: "/cache", Reference: "ghcr.io/rancher/k3s:latest", Digest: testDigest}
+		assert.Equal(t, "ghcr.io/rancher/k3s@"+testDigest, pkg.PinnedReference())
+	}
+
+	{ // local
+		pkg := Package{RootPath: "/home/rancher/k3s"}
+		assert.Equal(t, "/home/rancher/k3s", pkg.PinnedReference())
+	}
+}
@@ -16,13 +16,23 @@ import (
 
 type Command struct {
 	// shell fields
-	Command       string   `yaml:"command,omitempty"`
-	NodePoolNames []string `yaml:"node_pools,omitempty"`
-	Parallel      *bool    `yaml:"parallel,omitempty"`
+	Command       string   `yaml:"command,omitempty" json:"command,omitempty"`
+	NodePoolNames []string `yaml:"node_pools,omitempty" json:"node_pools,omitempty"`
+	Parallel      *bool    `yaml:"parallel,omitempty" json:"parallel,omitempty"`
 
 	// terraform module fields
-	Module      string `yaml:"module,omitempty"`
-	SkipCleanup bool   `yaml:"skip_cleanup,omitempty"`
+	Module      string `yaml:"module,omitempty" json:"module,omitempty"`
+	SkipCleanup bool   `yaml:"skip_cleanup,omitempty" json:"skip_cleanup,omitempty"`
+}
+
+// Dependency is a package that is composed into the package depending on it when the package is loaded.
+type Dependency struct {
+	// Name namespaces the terraform modules of the dependency, it defaults to the name of the dependency's manifest.
+	Name string `yaml:"name,omitempty"`
+	// Package is an OCI reference, git repository, archive or a path relative to the depending package.
+	Package string `yaml:"package"`
+	// Version is a tag or version constraint for OCI references.
+	Version string `yaml:"version,omitempty"`
 }
 
 type VariableSchemas map[string]Schema
@@ -34,6 +44,7 @@ type Manifest struct {
 	Commands        []Command         `yaml:"commands"`
 	Overlay         map[string]string `yaml:"overlay,omitempty"`
 	VariableSchemas VariableSchemas   `yaml:"variables,omitempty"`
+	Dependencies    []Dependency      `yaml:"dependencies,omitempty"`
 }
 
 //go:embed package-manifest.schema.json
@@ -67,7 +78,14 @@ func LoadManifest(_fs fs.FS, path string) (Manifest, error) {
 	}
 	_ = f.Close()
 
-	err = ValidateManifest(buf)
+	return ParseManifest(buf)
+}
+
+// ParseManifest validates and parses the given manifest.
+func ParseManifest(buf []byte) (Manifest, error) {
+	var manifest Manifest
+
+	err := ValidateManifest(buf)
 	if err != nil {
 		return manifest, err
 	}
@@ -85,19 +103,27 @@ func LoadManifest(_fs fs.FS, path string) (Manifest, error) {
 }
 
 func (m *Manifest) ApplyDefaultVars(vs vars.VarSet) error {
-	for k, schema := range m.VariableSchemas {
+	for k, v := range m.DefaultVars() {
 		if _, ok := vs[k]; !ok {
-			if schema.Default == nil {
-				continue
-			}
-			value := schema.Default
-			vs[k] = value
+			vs[k] = v
 		}
 	}
 
 	return nil
 }
 
+// DefaultVars returns the default value of each variable that has one.
+func (m *Manifest) DefaultVars() vars.VarSet {
+	vs := vars.VarSet{}
+	for k, schema := range m.VariableSchemas {
+		if schema.Default != nil {
+			vs[k] = schema.Default
+		}
+	}
+
+	return vs
+}
+
 // ValidateDefaults returns an error if the var set does not match the manifest variable schemas.
 func (m *Manifest) ValidateDefaults() error {
 	for _, schema := range m.VariableSchemas {
@@ -120,7 +146,7 @@ func (m *Manifest) ValidateVarSet(vs vars.VarSet, write bool) error {
 			return fmt.Errorf("[%s] may not be set", k)
 		}
 
-		if _, ok := vs[k]; !schema.Optional && !schema.ReadOnly && schema.Default == nil && !ok {
+		if _, ok := vs[k]; schema.Required() && !ok {
 			return fmt.Errorf("[%s] is required", k)
 		}
 
@@ -132,6 +158,19 @@ func (m *Manifest) ValidateVarSet(vs vars.VarSet, write bool) error {
 	return nil
 }
 
+// MissingVars returns the sorted names of the required variables that are not set in the var set.
+func (m *Manifest) MissingVars(vs vars.VarSet) []string {
+	var missing []string
+	for _, k := range sortedKeys(m.VariableSchemas) {
+		schema := m.VariableSchemas[k]
+		if _, ok := vs[k]; schema.Required() && !ok {
+			missing = append(missing, k)
+		}
+	}
+
+	return missing
+}
+
 // FilterVars returns the given VarSet without any variables not defined in the manifest
 func (m *Manifest) FilterVars(vs vars.VarSet) vars.VarSet {
 	rval := vars.VarSet{}
@@ -230,8 +269,9 @@ func (s *VariableSchemas) UnmarshalYAML(unmarshal func(any) error) error {
 				schema.Sensitive = true
 			}
 
-			if val, okk := vv["optional"].(bool); okk && val {
-				schema.Optional = true
+			if val, okk := vv["optional"].(bool); okk {
+				schema.Optional = val
+				schema.required = !val
 			}
 
 			if val, okk := vv["readOnly"].(bool); okk && val {
@@ -132,6 +132,59 @@ func TestValidateVarSet(t *testing.T) {
 	}
 }
 
+func TestMissingVars(t *testing.T) {
+	manifest, _ := _package.LoadManifest(_fs, "tests/valid.yaml")
+
+	assert.Equal(t, []string{"a", "c"}, manifest.MissingVars(vars.VarSet{}))
+	assert.Equal(t, []string{"c"}, manifest.MissingVars(vars.VarSet{"a": "aval"}))
+	assert.Empty(t, manifest.MissingVars(vars.VarSet{"a": "aval", "c": "cval"}))
+}
+
+func TestSchemaRequired(t *testing.T) {
+	manifest, _ := _package.LoadManifest(_fs, "tests/valid.yaml")
+
+	assert.True(t, manifest.VariableSchemas["a"].Required())
+	assert.False(t, manifest.VariableSchemas["b"].Required(), "read only")
+	assert.True(t, manifest.VariableSchemas["c"].Required())
+	assert.False(t, manifest.VariableSchemas["d"].Required(), "optional")
+	assert.False(t, manifest.VariableSchemas["e"].Required(), "default")
+}
+
+func TestSchemaParse(t *testing.T) {
+	manifest, _ := _package.LoadManifest(_fs, "tests/valid.yaml")
+
+	{ // strings are used as they are
+		v, err := manifest.VariableSchemas["a"].Parse("123")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "123", v)
+	}
+
+	{ // other types are parsed
+		v, err := manifest.VariableSchemas["b"].Parse("12")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 12., v)
+	}
+
+	{ // the value must match the schema
+		_, err := manifest.VariableSchemas["b"].Parse("twelve")
+
+		assert.Error(t, err)
+	}
+
+	{ // booleans
+		v, err := manifest.VariableSchemas["d"].Parse("true")
+
+		assert.NoError(t, err)
+		assert.Equal(t, true, v)
+
+		_, err = manifest.VariableSchemas["d"].Parse("yes")
+
+		assert.Error(t, err)
+	}
+}
+
 func TestFilterVars(t *testing.T) {
 	manifest, _ := _package.LoadManifest(_fs, "tests/valid.yaml")
 
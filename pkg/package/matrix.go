@@ -0,0 +1,293 @@
+package _package
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"time"
+
+	"github.com/rancherlabs/corral/pkg/vars"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidTestMatrix = errors.New("invalid test matrix")
+
+// TestMatrix describes the corrals created by corral package test and the steps run against each of them.
+type TestMatrix struct {
+	// Package is the package under test, relative paths are resolved against the directory of the matrix file.
+	Package string `yaml:"package,omitempty"`
+	// Cases are the variable sets a corral is created with.
+	Cases []TestCase `yaml:"cases"`
+	// Steps are run against the corral of every case.
+	Steps []TestStep `yaml:"steps,omitempty"`
+}
+
+type TestCase struct {
+	Name      string         `yaml:"name"`
+	Variables map[string]any `yaml:"variables,omitempty"`
+	// Steps are run after the steps of the matrix.
+	Steps []TestStep `yaml:"steps,omitempty"`
+}
+
+// TestStep runs a command on the nodes of the given node pools and then compares the corral's variables.  The
+// command fails the step if it exits with a non-zero status on any node.  Variables set by the command with
+// corral_set are compared along with the corral's variables.
+type TestStep struct {
+	Name      string   `yaml:"name"`
+	Command   string   `yaml:"command,omitempty"`
+	NodePools []string `yaml:"node_pools,omitempty"`
+	// Vars are the expected values of variables.
+	Vars map[string]any `yaml:"vars,omitempty"`
+	// Match are regular expressions the string values of variables must match.
+	Match map[string]string `yaml:"match,omitempty"`
+}
+
+// LoadTestMatrix reads and validates the test matrix at path.
+func LoadTestMatrix(path string) (TestMatrix, error) {
+	var m TestMatrix
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return m, err
+	}
+
+	if err = yaml.Unmarshal(buf, &m); err != nil {
+		return m, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if m.Package != "" && !filepath.IsAbs(m.Package) {
+		if _, err := os.Stat(filepath.Join(filepath.Dir(path), m.Package)); err == nil {
+			m.Package = filepath.Join(filepath.Dir(path), m.Package)
+		}
+	}
+
+	return m, m.Validate()
+}
+
+// Validate returns an error if the matrix has no cases, a case name cannot be used in a corral name or a step is
+// incomplete.
+func (m TestMatrix) Validate() error {
+	if len(m.Cases) == 0 {
+		return fmt.Errorf("%w: no cases", ErrInvalidTestMatrix)
+	}
+
+	seen := map[string]bool{}
+	for _, c := range m.Cases {
+		if !dependencyNameRegexp.MatchString(c.Name) {
+			return fmt.Errorf("%w: case [%s] must have a name containing only letters, digits, '_', '.' and '-'", ErrInvalidTestMatrix, c.Name)
+		}
+
+		if seen[c.Name] {
+			return fmt.Errorf("%w: duplicate case [%s]", ErrInvalidTestMatrix, c.Name)
+		}
+		seen[c.Name] = true
+
+		for _, s := range append(m.Steps, c.Steps...) {
+			if err := s.validate(); err != nil {
+				return fmt.Errorf("%w: case [%s] step [%s]: %s", ErrInvalidTestMatrix, c.Name, s.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// StepsFor returns the steps run against the corral of the given case.
+func (m TestMatrix) StepsFor(c TestCase) []TestStep {
+	return append(append([]TestStep{}, m.Steps...), c.Steps...)
+}
+
+func (s TestStep) validate() error {
+	if s.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if s.Command == "" && len(s.Vars) == 0 && len(s.Match) == 0 {
+		return errors.New("a command, vars or match is required")
+	}
+
+	if s.Command != "" && len(s.NodePools) == 0 {
+		return errors.New("commands require node_pools")
+	}
+
+	for k, expr := range s.Match {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("match [%s]: %w", k, err)
+		}
+	}
+
+	return nil
+}
+
+// CheckVars returns a failure message for each variable that does not have the expected value or does not match
+// its regular expression.
+func (s TestStep) CheckVars(vs vars.VarSet) []string {
+	var failures []string
+
+	for _, k := range sortedKeys(s.Vars) {
+		actual, ok := vs[k]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("[%s] is not set", k))
+			continue
+		}
+
+		if !equalValues(s.Vars[k], actual) {
+			failures = append(failures, fmt.Sprintf("[%s] is %v, expected %v", k, actual, s.Vars[k]))
+		}
+	}
+
+	for _, k := range sortedKeys(s.Match) {
+		actual, ok := vs[k]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("[%s] is not set", k))
+			continue
+		}
+
+		if !regexp.MustCompile(s.Match[k]).MatchString(fmt.Sprint(actual)) {
+			failures = append(failures, fmt.Sprintf("[%s] is %v, expected a match for %s", k, actual, s.Match[k]))
+		}
+	}
+
+	return failures
+}
+
+// equalValues compares values as json so numbers and maps parsed from yaml equal the same values from corral vars.
+func equalValues(expected, actual any) bool {
+	normalize := func(v any) any {
+		buf, err := json.Marshal(toStringKeys(v))
+		if err != nil {
+			return v
+		}
+
+		var out any
+		_ = json.Unmarshal(buf, &out)
+		return out
+	}
+
+	return reflect.DeepEqual(normalize(expected), normalize(actual))
+}
+
+// TestResult is the result of a step of a test case.
+type TestResult struct {
+	Case     string
+	Step     string
+	Duration time.Duration
+	// Failure is empty if the step passed.
+	Failure string
+	Skipped bool
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnitReport writes the results as a JUnit report with a test suite for each case.
+func WriteJUnitReport(w io.Writer, name string, results []TestResult) error {
+	report := junitTestSuites{Name: name}
+
+	var total time.Duration
+	suites := map[string]int{}
+	for _, r := range results {
+		i, ok := suites[r.Case]
+		if !ok {
+			i = len(report.Suites)
+			suites[r.Case] = i
+			report.Suites = append(report.Suites, junitTestSuite{Name: r.Case})
+		}
+
+		suite := &report.Suites[i]
+		tc := junitTestCase{
+			Name:      r.Step,
+			ClassName: name + "." + r.Case,
+			Time:      seconds(r.Duration),
+		}
+
+		suite.Tests++
+		report.Tests++
+		switch {
+		case r.Skipped:
+			tc.Skipped = &struct{}{}
+			suite.Skipped++
+			report.Skipped++
+		case r.Failure != "":
+			tc.Failure = &junitFailure{Message: firstLine(r.Failure), Text: r.Failure}
+			suite.Failures++
+			report.Failures++
+		}
+
+		suite.Cases = append(suite.Cases, tc)
+		total += r.Duration
+	}
+
+	for i := range report.Suites {
+		var d time.Duration
+		for _, r := range results {
+			if r.Case == report.Suites[i].Name {
+				d += r.Duration
+			}
+		}
+		report.Suites[i].Time = seconds(d)
+	}
+	report.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func firstLine(s string) string {
+	for i, c := range s {
+		if c == '\n' {
+			return s[:i]
+		}
+	}
+
+	return s
+}
@@ -0,0 +1,127 @@
+package _package
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rancherlabs/corral/pkg/vars"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTestMatrix(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg"), 0o700))
+
+	path := filepath.Join(dir, "corral-test.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+package: pkg
+cases:
+  - name: single
+    variables:
+      node_count: 1
+  - name: ha
+    variables:
+      node_count: 3
+    steps:
+      - name: count
+        vars:
+          node_count: 3
+steps:
+  - name: ssh
+    command: "true"
+    node_pools: [nodes]
+`), 0o600))
+
+	m, err := LoadTestMatrix(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(dir, "pkg"), m.Package)
+	assert.Len(t, m.StepsFor(m.Cases[0]), 1)
+
+	steps := m.StepsFor(m.Cases[1])
+	require.Len(t, steps, 2)
+	assert.Equal(t, "ssh", steps[0].Name)
+	assert.Equal(t, "count", steps[1].Name)
+}
+
+func TestTestMatrixValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		matrix TestMatrix
+	}{
+		{"no cases", TestMatrix{}},
+		{"invalid case name", TestMatrix{Cases: []TestCase{{Name: "a b"}}}},
+		{"duplicate case", TestMatrix{Cases: []TestCase{{Name: "a"}, {Name: "a"}}}},
+		{"unnamed step", TestMatrix{Cases: []TestCase{{Name: "a"}}, Steps: []TestStep{{Command: "true", NodePools: []string{"all"}}}}},
+		{"empty step", TestMatrix{Cases: []TestCase{{Name: "a", Steps: []TestStep{{Name: "empty"}}}}}},
+		{"command without node pools", TestMatrix{Cases: []TestCase{{Name: "a"}}, Steps: []TestStep{{Name: "s", Command: "true"}}}},
+		{"invalid regex", TestMatrix{Cases: []TestCase{{Name: "a"}}, Steps: []TestStep{{Name: "s", Match: map[string]string{"a": "("}}}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.ErrorIs(t, test.matrix.Validate(), ErrInvalidTestMatrix)
+		})
+	}
+}
+
+func TestTestStepCheckVars(t *testing.T) {
+	step := TestStep{
+		Name: "vars",
+		Vars: map[string]any{
+			"count":   3,
+			"host":    "node-0",
+			"object":  map[string]any{"a": []any{1, "b"}},
+			"missing": "x",
+		},
+		Match: map[string]string{
+			"host":  "^node-[0-9]+$",
+			"other": "^a",
+		},
+	}
+
+	failures := step.CheckVars(vars.VarSet{
+		"count":  float64(3),
+		"host":   "node-0",
+		"object": map[string]any{"a": []any{float64(1), "b"}},
+		"other":  "b",
+	})
+
+	assert.Equal(t, []string{
+		"[missing] is not set",
+		"[other] is b, expected a match for ^a",
+	}, failures)
+}
+
+func TestWriteJUnitReport(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJUnitReport(&buf, "pkg", []TestResult{
+		{Case: "single", Step: "create", Duration: time.Second},
+		{Case: "single", Step: "ssh", Duration: 2 * time.Second, Failure: "exit status 1\nmore"},
+		{Case: "ha", Step: "create", Failure: "corral is ERROR"},
+		{Case: "ha", Step: "ssh", Skipped: true},
+	}))
+
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="pkg" tests="4" failures="2" skipped="1" time="3.000">
+  <testsuite name="single" tests="2" failures="1" skipped="0" time="3.000">
+    <testcase name="create" classname="pkg.single" time="1.000"></testcase>
+    <testcase name="ssh" classname="pkg.single" time="2.000">
+      <failure message="exit status 1">exit status 1&#xA;more</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="ha" tests="2" failures="1" skipped="1" time="0.000">
+    <testcase name="create" classname="pkg.ha" time="0.000">
+      <failure message="corral is ERROR">corral is ERROR</failure>
+    </testcase>
+    <testcase name="ssh" classname="pkg.ha" time="0.000">
+      <skipped></skipped>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, buf.String())
+}
@@ -0,0 +1,315 @@
+package _package
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/rancherlabs/corral/pkg/config"
+	"github.com/sirupsen/logrus"
+	"oras.land/oras-go/pkg/registry"
+)
+
+var ErrNotCached = errors.New("package is not cached")
+
+// cachedTagsDir is the directory in a cached repository that maps tags to the digest they last resolved to.  Valid
+// repository path components cannot start with an underscore, so it cannot collide with a repository.
+const cachedTagsDir = "_tags"
+
+// cachedSignaturesDir is the directory in a cached repository that records the key each digest was verified with
+// when it was downloaded.
+const cachedSignaturesDir = "_signatures"
+
+// cachedVerification records that a package digest was verified with the key with the given id.
+type cachedVerification struct {
+	Digest string `json:"digest"`
+	KeyID  string `json:"key_id"`
+}
+
+// LoadOption configures how packages are loaded.
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	offline          *bool
+	skipDependencies bool
+	config           config.Config
+}
+
+// WithOffline loads remote packages from the package cache without contacting the registry.  If this option is not
+// given the offline setting from the corral config is used.
+func WithOffline(offline bool) LoadOption {
+	return func(o *loadOptions) {
+		o.offline = &offline
+	}
+}
+
+// WithDependencies controls whether the dependencies of a package are composed into it.  Dependencies are composed
+// unless this option is false, which loads the package as it is published.
+func WithDependencies(resolve bool) LoadOption {
+	return func(o *loadOptions) {
+		o.skipDependencies = !resolve
+	}
+}
+
+func newLoadOptions(opts []LoadOption) (o loadOptions, err error) {
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	o.config, err = loadConfig()
+	if err != nil {
+		return
+	}
+
+	if o.offline == nil {
+		o.offline = &o.config.Offline
+	}
+
+	return
+}
+
+// loadCachedPackage loads a remote package from the package cache.  Tags resolve to the digest they most recently
+// resolved to online and version constraints are matched against the cached tags.
+func loadCachedPackage(ref string, policy config.SignaturePolicy) (pkg Package, err error) {
+	if repository, constraint, ok := parseConstraintRef(ref); ok {
+		r, err := registry.ParseReference(repository)
+		if err != nil {
+			return pkg, err
+		}
+
+		var tags []string
+		for tag := range cachedTags(repositoryPath(r)) {
+			tags = append(tags, tag)
+		}
+
+		tag, err := selectTag(constraint, tags)
+		if err != nil {
+			return pkg, fmt.Errorf("%w\n%s", err, cachedVersions(r))
+		}
+
+		logrus.Infof("Resolved %s to cached tag %s.", ref, tag)
+		ref = repository + ":" + tag
+	}
+
+	ref = normalizeRef(ref)
+
+	r, err := registry.ParseReference(ref)
+	if err != nil {
+		return pkg, err
+	}
+
+	d, err := r.Digest()
+	if err != nil {
+		var ok bool
+		if d, ok = cachedTags(repositoryPath(r))[r.Reference]; !ok {
+			// packages cached without a tag record fall back to the newest cached digest
+			if d, ok = newestCachedDigest(repositoryPath(r)); !ok {
+				return pkg, fmt.Errorf("%w: %s\n%s", ErrNotCached, ref, cachedVersions(r))
+			}
+
+			logrus.Warnf("tag %s was not recorded when it was downloaded, using the most recently used cached digest %s", ref, d)
+		}
+	}
+
+	cacheRoot := config.CorralRoot("cache", "packages")
+	dest := filepath.Join(cacheRoot, repositoryPath(r), d.Encoded())
+	if !isInside(cacheRoot, dest) {
+		return pkg, fmt.Errorf("%w: %s", ErrUnsafePath, ref)
+	}
+
+	if !isCachedPackageDir(dest) {
+		return pkg, fmt.Errorf("%w: %s\n%s", ErrNotCached, ref, cachedVersions(r))
+	}
+
+	if err = checkCachedVerification(r, d, policy); err != nil {
+		return pkg, err
+	}
+
+	touchCacheEntry(dest)
+
+	pkg, err = loadLocalPackage(dest)
+	pkg.Reference = ref
+	pkg.Digest = d.String()
+
+	return
+}
+
+// recordTag records the digest a tagged reference resolved to so it can be loaded offline.
+func recordTag(ref string, d digest.Digest) {
+	r, err := registry.ParseReference(ref)
+	if err != nil {
+		return
+	}
+
+	if _, err = r.Digest(); err == nil {
+		return // not a tag
+	}
+
+	dir := config.CorralRoot("cache", "packages", repositoryPath(r), cachedTagsDir)
+	if err = os.MkdirAll(dir, 0o700); err == nil {
+		err = os.WriteFile(filepath.Join(dir, r.Reference), []byte(d.String()), 0o600)
+	}
+	if err != nil {
+		logrus.Debugf("failed to record tag %s: %s", ref, err)
+	}
+}
+
+// recordVerification records the key a package digest was verified with so the signature policy can be enforced
+// offline.
+func recordVerification(ref string, d digest.Digest, keyID string) {
+	if keyID == "" {
+		return
+	}
+
+	r, err := registry.ParseReference(ref)
+	if err != nil {
+		return
+	}
+
+	buf, _ := json.Marshal(cachedVerification{Digest: d.String(), KeyID: keyID})
+
+	dir := config.CorralRoot("cache", "packages", repositoryPath(r), cachedSignaturesDir)
+	if err = os.MkdirAll(dir, 0o700); err == nil {
+		err = os.WriteFile(filepath.Join(dir, d.Encoded()), buf, 0o600)
+	}
+	if err != nil {
+		logrus.Debugf("failed to record the verification of %s: %s", ref, err)
+	}
+}
+
+// checkCachedVerification checks the recorded verification of a cached package against the policy.  Packages that
+// were not verified by a currently trusted key are refused if the policy requires signatures.
+func checkCachedVerification(r registry.Reference, d digest.Digest, policy config.SignaturePolicy) error {
+	if !policy.RequireSignatures && len(policy.TrustedKeys) == 0 {
+		return nil
+	}
+
+	trusted, err := trustedKeys(policy)
+	if err != nil {
+		return err
+	}
+
+	var v cachedVerification
+	buf, err := os.ReadFile(config.CorralRoot("cache", "packages", repositoryPath(r), cachedSignaturesDir, d.Encoded()))
+	if err == nil {
+		err = json.Unmarshal(buf, &v)
+	}
+
+	if _, ok := trusted[v.KeyID]; err == nil && ok && v.Digest == d.String() {
+		logrus.Debugf("package %s was verified with key %s when it was downloaded", r, v.KeyID)
+		return nil
+	}
+
+	if policy.RequireSignatures {
+		return fmt.Errorf("%s was not verified by a trusted key when it was downloaded: %w", r, ErrUntrustedPackage)
+	}
+
+	logrus.Warnf("package %s was not verified by a trusted key when it was downloaded", r)
+	return nil
+}
+
+// cachedTags returns the digest each cached tag of the repository at the given path resolved to.
+func cachedTags(repoPath string) map[string]digest.Digest {
+	dir := config.CorralRoot("cache", "packages", repoPath, cachedTagsDir)
+	tags := map[string]digest.Digest{}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return tags
+	}
+
+	for _, entry := range entries {
+		buf, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		d, err := digest.Parse(strings.TrimSpace(string(buf)))
+		if err != nil {
+			continue
+		}
+
+		tags[entry.Name()] = d
+	}
+
+	return tags
+}
+
+// newestCachedDigest returns the digest of the most recently used or modified package cached in the repository at the
+// given path.
+func newestCachedDigest(repoPath string) (d digest.Digest, ok bool) {
+	dir := config.CorralRoot("cache", "packages", repoPath)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	var newest time.Time
+	for _, entry := range entries {
+		if !entry.IsDir() || !isCachedPackageDir(filepath.Join(dir, entry.Name())) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		if !ok || info.ModTime().After(newest) {
+			d, ok, newest = digest.NewDigestFromEncoded(digest.SHA256, entry.Name()), true, info.ModTime()
+		}
+	}
+
+	return
+}
+
+// cachedVersions describes the cached versions of a repository for error messages.
+func cachedVersions(r registry.Reference) string {
+	repoPath := repositoryPath(r)
+	repository := r.Registry + "/" + r.Repository
+
+	tagsByDigest := map[digest.Digest][]string{}
+	for tag, d := range cachedTags(repoPath) {
+		tagsByDigest[d] = append(tagsByDigest[d], tag)
+	}
+
+	entries, _ := os.ReadDir(config.CorralRoot("cache", "packages", repoPath))
+
+	var versions []string
+	for _, entry := range entries {
+		if !entry.IsDir() || !isCachedPackageDir(config.CorralRoot("cache", "packages", repoPath, entry.Name())) {
+			continue
+		}
+
+		d := digest.NewDigestFromEncoded(digest.SHA256, entry.Name())
+		tags := tagsByDigest[d]
+		sort.Strings(tags)
+
+		for _, tag := range tags {
+			versions = append(versions, fmt.Sprintf("  %s:%s (%s)", repository, tag, d))
+		}
+		if len(tags) == 0 {
+			versions = append(versions, fmt.Sprintf("  %s@%s", repository, d))
+		}
+	}
+
+	if len(versions) == 0 {
+		return fmt.Sprintf("no versions of %s are cached", repository)
+	}
+
+	sort.Strings(versions)
+
+	return "cached versions:\n" + strings.Join(versions, "\n")
+}
+
+// repositoryPath returns the path of a repository relative to the package cache.
+func repositoryPath(r registry.Reference) string {
+	return filepath.Join(append([]string{r.Registry}, strings.Split(r.Repository, "/")...)...)
+}
@@ -0,0 +1,132 @@
+package _package
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/rancherlabs/corral/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCachedPackage(t *testing.T) {
+	config.InitializeRootPath(t.TempDir())
+
+	older := digest.NewDigestFromEncoded(digest.SHA256, filepath.Base(writeCachedPackage(t, "ghcr.io/rancher/k3s", "v1.24")))
+	newer := digest.NewDigestFromEncoded(digest.SHA256, filepath.Base(writeCachedPackage(t, "ghcr.io/rancher/k3s", "v1.25")))
+
+	recordTag("ghcr.io/rancher/k3s:v1.24.1", older)
+	recordTag("ghcr.io/rancher/k3s:v1.25.0", older)
+	recordTag("ghcr.io/rancher/k3s:v1.25.0", newer) // the tag was moved
+	recordTag("ghcr.io/rancher/k3s@"+newer.String(), newer)
+
+	tests := []struct {
+		ref    string
+		digest digest.Digest
+	}{
+		{ref: "ghcr.io/rancher/k3s:v1.24.1", digest: older},
+		{ref: "ghcr.io/rancher/k3s:v1.25.0", digest: newer},
+		{ref: "ghcr.io/rancher/k3s@" + older.String(), digest: older},
+		{ref: "ghcr.io/rancher/k3s@~1.24", digest: older},
+		{ref: "ghcr.io/rancher/k3s:>=1.24", digest: newer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			pkg, err := loadCachedPackage(tt.ref, config.SignaturePolicy{})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.digest.String(), pkg.Digest)
+			assert.Equal(t, config.CorralRoot("cache", "packages", "ghcr.io", "rancher", "k3s", tt.digest.Encoded()), pkg.RootPath)
+		})
+	}
+
+	// tags that were not recorded resolve to the most recently used digest
+	now := time.Now()
+	require.NoError(t, os.Chtimes(config.CorralRoot("cache", "packages", "ghcr.io", "rancher", "k3s", older.Encoded()), now, now))
+	require.NoError(t, os.Chtimes(config.CorralRoot("cache", "packages", "ghcr.io", "rancher", "k3s", newer.Encoded()), now.Add(-time.Hour), now.Add(-time.Hour)))
+
+	pkg, err := loadCachedPackage("ghcr.io/rancher/k3s:v1.26.0", config.SignaturePolicy{})
+	require.NoError(t, err)
+	assert.Equal(t, older.String(), pkg.Digest)
+
+	_, err = loadCachedPackage("ghcr.io/rancher/k3s@~1.26", config.SignaturePolicy{})
+	assert.ErrorIs(t, err, ErrNoMatchingTag)
+	assert.Contains(t, err.Error(), "ghcr.io/rancher/k3s:v1.25.0 ("+newer.String()+")")
+	assert.Contains(t, err.Error(), "ghcr.io/rancher/k3s:v1.24.1 ("+older.String()+")")
+
+	_, err = loadCachedPackage("ghcr.io/rancher/rke2:latest", config.SignaturePolicy{})
+	assert.ErrorIs(t, err, ErrNotCached)
+	assert.Contains(t, err.Error(), "no versions of ghcr.io/rancher/rke2 are cached")
+}
+
+func TestLoadCachedPackageSignaturePolicy(t *testing.T) {
+	config.InitializeRootPath(t.TempDir())
+
+	dir := t.TempDir()
+	trustedKey := filepath.Join(dir, "trusted.key")
+	otherKey := filepath.Join(dir, "other.key")
+	require.NoError(t, GenerateSigningKey(trustedKey))
+	require.NoError(t, GenerateSigningKey(otherKey))
+
+	trustedPub, err := LoadVerificationKey(trustedKey + ".pub")
+	require.NoError(t, err)
+	otherPub, err := LoadVerificationKey(otherKey + ".pub")
+	require.NoError(t, err)
+
+	signed := digest.NewDigestFromEncoded(digest.SHA256, filepath.Base(writeCachedPackage(t, "ghcr.io/rancher/k3s", "signed")))
+	untrusted := digest.NewDigestFromEncoded(digest.SHA256, filepath.Base(writeCachedPackage(t, "ghcr.io/rancher/k3s", "untrusted")))
+	unsigned := digest.NewDigestFromEncoded(digest.SHA256, filepath.Base(writeCachedPackage(t, "ghcr.io/rancher/k3s", "unsigned")))
+
+	recordVerification("ghcr.io/rancher/k3s:signed", signed, KeyID(trustedPub))
+	recordVerification("ghcr.io/rancher/k3s:untrusted", untrusted, KeyID(otherPub))
+	recordVerification("ghcr.io/rancher/k3s:unsigned", unsigned, "")
+
+	trusted := config.SignaturePolicy{TrustedKeys: []string{trustedKey + ".pub"}}
+	required := config.SignaturePolicy{RequireSignatures: true, TrustedKeys: []string{trustedKey + ".pub"}}
+
+	for _, d := range []digest.Digest{signed, untrusted, unsigned} {
+		ref := "ghcr.io/rancher/k3s@" + d.String()
+
+		_, err = loadCachedPackage(ref, config.SignaturePolicy{})
+		assert.NoError(t, err)
+
+		_, err = loadCachedPackage(ref, trusted)
+		assert.NoError(t, err)
+
+		_, err = loadCachedPackage(ref, required)
+		if d == signed {
+			assert.NoError(t, err)
+		} else {
+			assert.ErrorIs(t, err, ErrUntrustedPackage)
+		}
+	}
+
+	_, err = loadCachedPackage("ghcr.io/rancher/k3s@"+signed.String(), config.SignaturePolicy{RequireSignatures: true})
+	assert.ErrorIs(t, err, ErrNoTrustedKeys)
+}
+
+func TestLoadCachedPackageWithoutTags(t *testing.T) {
+	config.InitializeRootPath(t.TempDir())
+
+	older := writeCachedPackage(t, "ghcr.io/rancher/k3s", "v1.24")
+	newer := writeCachedPackage(t, "ghcr.io/rancher/k3s", "v1.25")
+
+	now := time.Now()
+	require.NoError(t, os.Chtimes(older, now, now))
+	require.NoError(t, os.Chtimes(newer, now.Add(-time.Hour), now.Add(-time.Hour)))
+
+	_, err := os.Stat(config.CorralRoot("cache", "packages", "ghcr.io", "rancher", "k3s", cachedTagsDir))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	pkg, err := loadCachedPackage("ghcr.io/rancher/k3s:latest", config.SignaturePolicy{})
+	require.NoError(t, err)
+	assert.Equal(t, older, pkg.RootPath)
+	assert.Equal(t, "sha256:"+filepath.Base(older), pkg.Digest)
+
+	_, err = loadCachedPackage("ghcr.io/rancher/rke2:latest", config.SignaturePolicy{})
+	assert.ErrorIs(t, err, ErrNotCached)
+}
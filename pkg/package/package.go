@@ -1,9 +1,13 @@
 package _package
 
 import (
+	"errors"
+	"io/fs"
 	"path/filepath"
 
+	"github.com/rancherlabs/corral/pkg/config"
 	"github.com/rancherlabs/corral/pkg/version"
+	"oras.land/oras-go/pkg/registry"
 )
 
 var corralUserAgent = "Corral/" + version.Version
@@ -15,9 +19,22 @@ const (
 	PublishTimestampAnnotation = "corral.cattle.io/published-at"
 )
 
+// Packages published before these media types were introduced store the manifest as a config with
+// v1.MediaTypeImageLayer and every layer as v1.MediaTypeImageLayerGzip.
+const (
+	ConfigMediaType         = "application/vnd.cattle.corral.config.v1+yaml"
+	ManifestLayerMediaType  = "application/vnd.cattle.corral.manifest.v1.tar+gzip"
+	TerraformLayerMediaType = "application/vnd.cattle.corral.terraform.v1.tar+gzip"
+	OverlayLayerMediaType   = "application/vnd.cattle.corral.overlay.v1.tar+gzip"
+)
+
 type Package struct {
 	RootPath string
 
+	// Reference and Digest are set for packages loaded from an OCI registry.
+	Reference string
+	Digest    string
+
 	Manifest
 }
 
@@ -31,6 +48,25 @@ func (b Package) TerraformVersion() string {
 	return v
 }
 
+// PinnedReference returns a reference that will always load this exact package.  Local packages return their path
+// and packages from git repositories are pinned to a commit.
+func (b Package) PinnedReference() string {
+	if b.Digest == "" {
+		if b.Reference != "" {
+			return b.Reference
+		}
+		return b.RootPath
+	}
+
+	r, err := registry.ParseReference(b.Reference)
+	if err != nil {
+		return b.Reference
+	}
+	r.Reference = b.Digest
+
+	return r.String()
+}
+
 func (b Package) ManifestPath() string {
 	return filepath.Join(b.RootPath, "manifest.yaml")
 }
@@ -42,3 +78,13 @@ func (b Package) TerraformModulePath(name string) string {
 func (b *Package) OverlayPath() string {
 	return filepath.Join(b.RootPath, "overlay")
 }
+
+// loadConfig returns the global configuration, or the defaults if corral has not been configured yet.
+func loadConfig() (config.Config, error) {
+	cfg, err := config.Load()
+	if errors.Is(err, fs.ErrNotExist) {
+		return config.Config{}, nil
+	}
+
+	return cfg, err
+}
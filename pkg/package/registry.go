@@ -1,44 +1,345 @@
 package _package
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"net/http"
+	"os"
+	"sort"
+	"sync"
 
+	"github.com/containerd/containerd/remotes/docker"
+	dockerconfig "github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/configfile"
+	dockertypes "github.com/docker/cli/cli/config/types"
 	"github.com/rancherlabs/corral/pkg/config"
-	"oras.land/oras-go/pkg/auth"
-	"oras.land/oras-go/pkg/auth/docker"
 	dockerauth "oras.land/oras-go/pkg/auth/docker"
 	"oras.land/oras-go/pkg/content"
+	"oras.land/oras-go/pkg/registry"
+	"oras.land/oras-go/pkg/registry/remote"
+	remoteauth "oras.land/oras-go/pkg/registry/remote/auth"
 )
 
-var registryCredentials = config.CorralRoot("registry-creds.json")
+// registryCredentials returns the path of the file registry credentials are stored in.  It must not be evaluated
+// before the corral root is initialized.
+func registryCredentials() string {
+	return config.CorralRoot("registry-creds.json")
+}
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrNotLoggedIn        = errors.New("not logged in")
+)
 
 func newRegistryStore() (reg content.Registry, err error) {
-	authorizer, err := docker.NewClient(registryCredentials)
+	cfg, err := loadConfig()
 	if err != nil {
 		return
 	}
 
-	headers := http.Header{}
-	headers.Set("User-Agent", corralUserAgent)
-	opts := []auth.ResolverOption{auth.WithResolverHeaders(headers)}
-	resolver, err := authorizer.ResolverWithOpts(opts...)
+	authorizer, err := newCredentialsClient()
 	if err != nil {
 		return
 	}
 
-	reg = content.Registry{Resolver: resolver}
+	headers := http.Header{}
+	headers.Set("User-Agent", corralUserAgent)
+
+	reg = content.Registry{Resolver: docker.NewResolver(docker.ResolverOptions{
+		Hosts:   registryHosts(cfg.Registries, authorizer.Credential, headers),
+		Headers: headers,
+	})}
 
 	return
 }
 
-func AddRegistryCredentials(registry, username, password string) error {
-	da, err := dockerauth.NewClient(registryCredentials)
+// registryHosts configures each registry with the connection settings from the global config.
+func registryHosts(registries map[string]config.RegistryConfig, creds func(string) (string, string, error), headers http.Header) docker.RegistryHosts {
+	var mu sync.Mutex
+	authorizers := map[string]docker.Authorizer{}
+
+	return func(host string) ([]docker.RegistryHost, error) {
+		rc := registries[host]
+
+		client, err := registryHTTPClient(rc)
+		if err != nil {
+			return nil, fmt.Errorf("registry %s: %w", host, err)
+		}
+
+		// reuse authorizers so tokens are cached between requests
+		mu.Lock()
+		authorizer, ok := authorizers[host]
+		if !ok {
+			authorizer = docker.NewDockerAuthorizer(
+				docker.WithAuthClient(client),
+				docker.WithAuthCreds(creds),
+				docker.WithAuthHeader(headers))
+			authorizers[host] = authorizer
+		}
+		mu.Unlock()
+
+		return docker.ConfigureDefaultRegistries(
+			docker.WithClient(client),
+			docker.WithAuthorizer(authorizer),
+			docker.WithPlainHTTP(func(host string) (bool, error) { return usePlainHTTP(registries, host), nil }),
+		)(host)
+	}
+}
+
+// usePlainHTTP returns true if the registry is configured to use http.  Registries without any configuration only use
+// http on localhost.
+func usePlainHTTP(registries map[string]config.RegistryConfig, host string) bool {
+	if rc, ok := registries[host]; ok {
+		return rc.PlainHTTP
+	}
+
+	plainHTTP, _ := docker.MatchLocalhost(host)
+	return plainHTTP
+}
+
+// registryHTTPClient returns an http client that uses the TLS settings of the registry.
+func registryHTTPClient(rc config.RegistryConfig) (*http.Client, error) {
+	if rc.CAFile == "" && !rc.Insecure {
+		return http.DefaultClient, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: rc.Insecure}
+
+	if rc.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		buf, err := os.ReadFile(rc.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if !pool.AppendCertsFromPEM(buf) {
+			return nil, fmt.Errorf("%s does not contain any PEM encoded certificates", rc.CAFile)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// AddRegistryCredentials verifies the credentials against the registry and stores them for future requests.
+func AddRegistryCredentials(host, username, password string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	rc := cfg.Registries[host]
+	client, err := registryHTTPClient(rc)
+	if err != nil {
+		return err
+	}
+
+	headers := http.Header{}
+	headers.Set("User-Agent", corralUserAgent)
+
+	authClient := &remoteauth.Client{
+		Client: client,
+		Header: headers,
+		Credential: func(context.Context, string) (remoteauth.Credential, error) {
+			return remoteauth.Credential{Username: username, Password: password}, nil
+		},
+	}
+
+	if err = pingRegistry(authClient, host, usePlainHTTP(cfg.Registries, host)); err != nil {
+		return err
+	}
+
+	store, err := loadCredentialsFile()
+	if err != nil {
+		return err
+	}
+
+	return store.GetCredentialsStore(credentialsHost(host)).Store(dockertypes.AuthConfig{
+		ServerAddress: credentialsHost(host),
+		Username:      username,
+		Password:      password,
+	})
+}
+
+// RemoveRegistryCredentials removes the credentials for host stored by AddRegistryCredentials.  Credentials from the
+// docker config are not removed.
+func RemoveRegistryCredentials(host string) error {
+	store, err := loadCredentialsFile()
+	if err != nil {
+		return err
+	}
+
+	server := credentialsHost(host)
+	if _, ok := store.AuthConfigs[server]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotLoggedIn, host)
+	}
+
+	return store.GetCredentialsStore(server).Erase(server)
+}
+
+// pingRegistry checks that the registry api of host can be accessed with the given client.
+func pingRegistry(client *remoteauth.Client, host string, plainHTTP bool) error {
+	scheme := "https"
+	if plainHTTP {
+		scheme = "http"
+	}
+
+	// docker hub serves the registry api from a different host
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s://%s/v2/", scheme, host), nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.Do(req)
 	if err != nil {
 		return err
 	}
+	_ = res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrInvalidCredentials
+	default:
+		return fmt.Errorf("unexpected response from %s: %s", host, res.Status)
+	}
+}
+
+// newCredentialsClient returns a client for the credentials stored by corral which falls back to the docker config
+// and its credential helpers.
+func newCredentialsClient() (*dockerauth.Client, error) {
+	client, err := dockerauth.NewClientWithDockerFallback(registryCredentials())
+	if err != nil {
+		return nil, err
+	}
+
+	return client.(*dockerauth.Client), nil
+}
+
+// loadCredentialsFile loads the docker config file corral stores registry credentials in.  Unless the file configures
+// otherwise credentials are kept in the credential store or helpers of the docker config so they are not written to
+// disk in plain text.
+func loadCredentialsFile() (*configfile.ConfigFile, error) {
+	cfg := configfile.New(registryCredentials())
+
+	f, err := os.Open(registryCredentials())
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		defer func() { _ = f.Close() }()
+
+		if err = cfg.LoadFromReader(f); err != nil {
+			return nil, err
+		}
+	}
+
+	if !cfg.ContainsAuth() {
+		dockerCfg := dockerconfig.LoadDefaultConfigFile(io.Discard)
+		cfg.CredentialsStore = dockerCfg.CredentialsStore
+		cfg.CredentialHelpers = dockerCfg.CredentialHelpers
+	}
+
+	return cfg, nil
+}
+
+// credentialsHost returns the key credentials for host are stored under, docker hub uses its legacy index address.
+func credentialsHost(host string) string {
+	switch host {
+	case "docker.io", "index.docker.io", "registry-1.docker.io":
+		return "https://index.docker.io/v1/"
+	}
+
+	return host
+}
+
+// ListTags returns the sorted tags of the given repository.
+func ListTags(repository string) ([]string, error) {
+	repo, err := newRepository(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	err = repo.Tags(context.Background(), func(page []string) error {
+		tags = append(tags, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(tags)
+
+	return tags, nil
+}
+
+// newRepository returns a client for the registry api of the given repository.
+func newRepository(repository string) (*remote.Repository, error) {
+	ref, err := registry.ParseReference(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	rc := cfg.Registries[ref.Registry]
+	client, err := registryHTTPClient(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := http.Header{}
+	headers.Set("User-Agent", corralUserAgent)
+
+	return &remote.Repository{
+		Reference: ref,
+		PlainHTTP: usePlainHTTP(cfg.Registries, ref.Registry),
+		Client: &remoteauth.Client{
+			Client:     client,
+			Header:     headers,
+			Cache:      remoteauth.NewCache(),
+			Credential: registryCredential,
+		},
+	}, nil
+}
+
+// registryCredential returns the stored credentials for the given registry host.
+func registryCredential(_ context.Context, host string) (remoteauth.Credential, error) {
+	client, err := newCredentialsClient()
+	if err != nil {
+		return remoteauth.EmptyCredential, err
+	}
+
+	username, secret, err := client.Credential(host)
+	if err != nil {
+		return remoteauth.EmptyCredential, err
+	}
+
+	if username == "" {
+		return remoteauth.Credential{RefreshToken: secret}, nil
+	}
 
-	return da.LoginWithOpts(auth.WithLoginHostname(registry),
-		auth.WithLoginUsername(username),
-		auth.WithLoginSecret(password),
-		auth.WithLoginUserAgent(corralUserAgent))
+	return remoteauth.Credential{Username: username, Password: secret}, nil
 }
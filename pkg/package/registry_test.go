@@ -0,0 +1,186 @@
+package _package
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	dockerconfig "github.com/docker/cli/cli/config"
+	dockertypes "github.com/docker/cli/cli/config/types"
+	"github.com/rancherlabs/corral/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	remoteauth "oras.land/oras-go/pkg/registry/remote/auth"
+)
+
+func TestRegistryHTTPClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, ca, 0o600))
+
+	tests := []struct {
+		name string
+		rc   config.RegistryConfig
+		ok   bool
+	}{
+		{name: "default", rc: config.RegistryConfig{}},
+		{name: "ca file", rc: config.RegistryConfig{CAFile: caFile}, ok: true},
+		{name: "insecure", rc: config.RegistryConfig{Insecure: true}, ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := registryHTTPClient(tt.rc)
+			require.NoError(t, err)
+
+			res, err := client.Get(server.URL)
+			if tt.ok {
+				require.NoError(t, err)
+				_ = res.Body.Close()
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	_, err := registryHTTPClient(config.RegistryConfig{CAFile: filepath.Join("tests", "valid.yaml")})
+	assert.Error(t, err)
+}
+
+func TestUsePlainHTTP(t *testing.T) {
+	registries := map[string]config.RegistryConfig{
+		"registry.local:5000": {PlainHTTP: true},
+		"localhost:5001":      {CAFile: "ca.pem"},
+	}
+
+	assert.True(t, usePlainHTTP(registries, "registry.local:5000"))
+	assert.False(t, usePlainHTTP(registries, "localhost:5001"))
+	assert.True(t, usePlainHTTP(registries, "localhost:5000"))
+	assert.False(t, usePlainHTTP(registries, "ghcr.io"))
+}
+
+func TestAddRegistryCredentials(t *testing.T) {
+	root := t.TempDir()
+	config.InitializeRootPath(root)
+	require.NoError(t, os.MkdirAll(config.CorralRoot(), 0o700))
+	useDockerConfig(t, `{}`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); ok && username == "rancher" && password == "secret" {
+			return
+		}
+
+		w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	cfg := config.Config{Registries: map[string]config.RegistryConfig{host: {PlainHTTP: true}}}
+	require.NoError(t, cfg.Save())
+
+	assert.ErrorIs(t, AddRegistryCredentials(host, "rancher", "wrong"), ErrInvalidCredentials)
+	require.NoError(t, AddRegistryCredentials(host, "rancher", "secret"))
+
+	assert.FileExists(t, filepath.Join(root, ".corral", "registry-creds.json"))
+
+	cred, err := registryCredential(nil, host)
+	require.NoError(t, err)
+	assert.Equal(t, "rancher", cred.Username)
+	assert.Equal(t, "secret", cred.Password)
+}
+
+func TestRemoveRegistryCredentials(t *testing.T) {
+	config.InitializeRootPath(t.TempDir())
+	require.NoError(t, os.MkdirAll(config.CorralRoot(), 0o700))
+	useDockerConfig(t, `{}`)
+
+	store, err := loadCredentialsFile()
+	require.NoError(t, err)
+	require.NoError(t, store.GetCredentialsStore("registry.local").Store(dockertypes.AuthConfig{
+		ServerAddress: "registry.local",
+		Username:      "rancher",
+		Password:      "secret",
+	}))
+
+	require.NoError(t, RemoveRegistryCredentials("registry.local"))
+	assert.ErrorIs(t, RemoveRegistryCredentials("registry.local"), ErrNotLoggedIn)
+
+	cred, err := registryCredential(nil, "registry.local")
+	require.NoError(t, err)
+	assert.Equal(t, remoteauth.EmptyCredential, cred)
+}
+
+func TestRegistryCredentialDockerFallback(t *testing.T) {
+	config.InitializeRootPath(t.TempDir())
+	require.NoError(t, os.MkdirAll(config.CorralRoot(), 0o700))
+
+	auth := base64.StdEncoding.EncodeToString([]byte("docker:password"))
+	useDockerConfig(t, `{"auths": {"registry.local": {"auth": "`+auth+`"}}}`)
+
+	cred, err := registryCredential(nil, "registry.local")
+	require.NoError(t, err)
+	assert.Equal(t, "docker", cred.Username)
+	assert.Equal(t, "password", cred.Password)
+}
+
+func TestRegistryCredentialHelper(t *testing.T) {
+	config.InitializeRootPath(t.TempDir())
+	require.NoError(t, os.MkdirAll(config.CorralRoot(), 0o700))
+	useDockerConfig(t, `{"credHelpers": {"registry.local": "corral-test"}}`)
+
+	// a credential helper that keeps a single credential in a file
+	bin := t.TempDir()
+	stored := filepath.Join(bin, "stored")
+	helper := `#!/bin/sh
+case "$1" in
+  store) cat > ` + stored + ` ;;
+  get) cat ` + stored + ` 2>/dev/null || { echo "credentials not found in native keychain"; exit 1; } ;;
+  erase) rm -f ` + stored + ` ;;
+esac
+`
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "docker-credential-corral-test"), []byte(helper), 0o700))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	store, err := loadCredentialsFile()
+	require.NoError(t, err)
+	require.NoError(t, store.GetCredentialsStore("registry.local").Store(dockertypes.AuthConfig{
+		ServerAddress: "registry.local",
+		Username:      "rancher",
+		Password:      "secret",
+	}))
+
+	assert.FileExists(t, stored)
+	buf, err := os.ReadFile(registryCredentials())
+	if err == nil {
+		assert.NotContains(t, string(buf), "secret")
+	}
+
+	cred, err := registryCredential(nil, "registry.local")
+	require.NoError(t, err)
+	assert.Equal(t, "rancher", cred.Username)
+	assert.Equal(t, "secret", cred.Password)
+
+	require.NoError(t, RemoveRegistryCredentials("registry.local"))
+	assert.NoFileExists(t, stored)
+}
+
+// useDockerConfig points the docker config at a temporary directory containing the given config.json.
+func useDockerConfig(t *testing.T, content string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.json"), []byte(content), 0o600))
+
+	prev := dockerconfig.Dir()
+	dockerconfig.SetDir(dir)
+	t.Cleanup(func() { dockerconfig.SetDir(prev) })
+}
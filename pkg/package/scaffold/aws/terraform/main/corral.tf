@@ -0,0 +1,33 @@
+{{ template "corral_variables" }}
+
+// Package variables from manifest.yaml
+variable "aws_access_key" {
+  type      = string
+  sensitive = true
+}
+
+variable "aws_secret_key" {
+  type      = string
+  sensitive = true
+}
+
+variable "aws_region" {
+  type = string
+}
+
+variable "node_count" {
+  type = number
+}
+
+// corral connects to the nodes in each pool to copy the overlay and run commands
+output "corral_node_pools" {
+  value = {
+    nodes = [
+      for instance in aws_instance.node : {
+        name    = instance.tags.Name
+        user    = "ubuntu"
+        address = instance.public_ip
+      }
+    ]
+  }
+}
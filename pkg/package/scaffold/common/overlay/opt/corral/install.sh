@@ -0,0 +1,19 @@
+#!/bin/bash
+set -e
+
+# corral_set sets a corral variable, it must be declared in manifest.yaml to be returned by corral vars.
+function corral_set() {
+    echo "corral_set $1=$2"
+}
+
+# corral_log prints a message for the corral user.
+function corral_log() {
+    echo "corral_log $1"
+}
+
+# corral variables are available as environment variables with the prefix CORRAL_
+mkdir -p "$HOME/.ssh"
+echo "$CORRAL_corral_user_public_key" >> "$HOME/.ssh/authorized_keys"
+
+corral_set node_hostname "$(hostname)"
+corral_log "$(hostname) is ready for corral $CORRAL_corral_name."
@@ -0,0 +1,28 @@
+{{ template "corral_variables" }}
+
+// Package variables from manifest.yaml
+variable "digitalocean_token" {
+  type      = string
+  sensitive = true
+}
+
+variable "digitalocean_region" {
+  type = string
+}
+
+variable "node_count" {
+  type = number
+}
+
+// corral connects to the nodes in each pool to copy the overlay and run commands
+output "corral_node_pools" {
+  value = {
+    nodes = [
+      for droplet in digitalocean_droplet.node : {
+        name    = droplet.name
+        user    = "root"
+        address = droplet.ipv4_address
+      }
+    ]
+  }
+}
@@ -0,0 +1,36 @@
+terraform {
+  required_providers {
+    digitalocean = {
+      source  = "digitalocean/digitalocean"
+      version = "~> 2.0"
+    }
+    random = {
+      source  = "hashicorp/random"
+      version = "~> 3.0"
+    }
+  }
+}
+
+provider "digitalocean" {
+  token = var.digitalocean_token
+}
+
+// distinguish resources with a random id to avoid collisions between corrals
+resource "random_id" "id" {
+  byte_length = 6
+}
+
+resource "digitalocean_ssh_key" "corral" {
+  name       = "${var.corral_user_id}-${random_id.id.hex}"
+  public_key = var.corral_public_key
+}
+
+resource "digitalocean_droplet" "node" {
+  count    = var.node_count
+  name     = "${var.corral_user_id}-${random_id.id.hex}-node-${count.index}"
+  image    = "ubuntu-22-04-x64"
+  region   = var.digitalocean_region
+  size     = "s-1vcpu-2gb"
+  tags     = [var.corral_user_id, random_id.id.hex]
+  ssh_keys = [digitalocean_ssh_key.corral.id]
+}
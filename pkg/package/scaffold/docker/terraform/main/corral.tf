@@ -0,0 +1,19 @@
+{{ template "corral_variables" }}
+
+// Package variables from manifest.yaml
+variable "node_count" {
+  type = number
+}
+
+// corral connects to the nodes in each pool to copy the overlay and run commands
+output "corral_node_pools" {
+  value = {
+    nodes = [
+      for node in docker_container.node : {
+        name    = node.name
+        user    = "corral"
+        address = "127.0.0.1:${node.ports[0].external}"
+      }
+    ]
+  }
+}
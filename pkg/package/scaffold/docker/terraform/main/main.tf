@@ -0,0 +1,26 @@
+terraform {
+  required_providers {
+    docker = {
+      source  = "kreuzwerker/docker"
+      version = "~> 2.13.0"
+    }
+  }
+}
+
+provider "docker" {}
+
+resource "docker_container" "node" {
+  count = var.node_count
+  image = "lscr.io/linuxserver/openssh-server"
+  name  = "${var.corral_name}-node-${count.index}"
+
+  ports {
+    internal = 2222
+  }
+
+  env = [
+    "PUBLIC_KEY=${var.corral_public_key}",
+    "USER_NAME=corral",
+    "SUDO_ACCESS=true",
+  ]
+}
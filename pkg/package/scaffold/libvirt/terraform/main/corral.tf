@@ -0,0 +1,23 @@
+{{ template "corral_variables" }}
+
+// Package variables from manifest.yaml
+variable "libvirt_uri" {
+  type = string
+}
+
+variable "node_count" {
+  type = number
+}
+
+// corral connects to the nodes in each pool to copy the overlay and run commands
+output "corral_node_pools" {
+  value = {
+    nodes = [
+      for domain in libvirt_domain.node : {
+        name    = domain.name
+        user    = "ubuntu"
+        address = domain.network_interface[0].addresses[0]
+      }
+    ]
+  }
+}
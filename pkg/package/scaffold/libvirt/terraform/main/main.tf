@@ -0,0 +1,55 @@
+terraform {
+  required_providers {
+    libvirt = {
+      source  = "dmacvicar/libvirt"
+      version = "~> 0.7.0"
+    }
+  }
+}
+
+provider "libvirt" {
+  uri = var.libvirt_uri
+}
+
+resource "libvirt_volume" "ubuntu" {
+  name   = "${var.corral_name}-ubuntu.qcow2"
+  source = "https://cloud-images.ubuntu.com/jammy/current/jammy-server-cloudimg-amd64.img"
+}
+
+resource "libvirt_volume" "node" {
+  count          = var.node_count
+  name           = "${var.corral_name}-node-${count.index}.qcow2"
+  base_volume_id = libvirt_volume.ubuntu.id
+  size           = 21474836480
+}
+
+// install the corral's public key with cloud-init so corral can connect to the nodes
+resource "libvirt_cloudinit_disk" "init" {
+  name      = "${var.corral_name}-init.iso"
+  user_data = <<-EOT
+    #cloud-config
+    users:
+      - name: ubuntu
+        sudo: ALL=(ALL) NOPASSWD:ALL
+        shell: /bin/bash
+        ssh_authorized_keys:
+          - ${var.corral_public_key}
+  EOT
+}
+
+resource "libvirt_domain" "node" {
+  count     = var.node_count
+  name      = "${var.corral_name}-node-${count.index}"
+  memory    = 2048
+  vcpu      = 2
+  cloudinit = libvirt_cloudinit_disk.init.id
+
+  disk {
+    volume_id = libvirt_volume.node[count.index].id
+  }
+
+  network_interface {
+    network_name   = "default"
+    wait_for_lease = true
+  }
+}
@@ -0,0 +1,364 @@
+package _package
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/rancherlabs/corral/pkg/config"
+	"github.com/sirupsen/logrus"
+	"oras.land/oras-go/pkg/content"
+	"oras.land/oras-go/pkg/oras"
+	"oras.land/oras-go/pkg/registry"
+	"oras.land/oras-go/pkg/target"
+)
+
+const (
+	SignatureMediaType       = "application/vnd.cattle.corral.signature.v1+json"
+	SignatureConfigMediaType = "application/vnd.cattle.corral.signature.config.v1+json"
+	SignedDigestAnnotation   = "corral.cattle.io/signed-digest"
+
+	privateKeyBlockType = "PRIVATE KEY"
+	publicKeyBlockType  = "PUBLIC KEY"
+)
+
+var (
+	ErrUnsigned          = errors.New("package is not signed")
+	ErrUntrustedPackage  = errors.New("package is not signed by a trusted key")
+	ErrNoTrustedKeys     = errors.New("signatures are required but no trusted keys are configured")
+	ErrInvalidSigningKey = errors.New("key is not an ed25519 key")
+)
+
+// Signature is an ed25519 signature of a package manifest digest.
+type Signature struct {
+	Digest    string `json:"digest"`
+	KeyID     string `json:"key_id"`
+	Signature []byte `json:"signature"`
+}
+
+// GenerateSigningKey writes a new ed25519 private key to path and the matching public key to path.pub.
+func GenerateSigningKey(path string) error {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return err
+	}
+
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: privateKeyBlockType, Bytes: privDER}), 0o600)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path+".pub", pem.EncodeToMemory(&pem.Block{Type: publicKeyBlockType, Bytes: pubDER}), 0o644)
+}
+
+// LoadSigningKey reads a PEM encoded ed25519 private key.
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(path, privateKeyBlockType)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", path, ErrInvalidSigningKey)
+	}
+
+	return priv, nil
+}
+
+// LoadVerificationKey reads a PEM encoded ed25519 public key.
+func LoadVerificationKey(path string) (ed25519.PublicKey, error) {
+	der, err := readPEM(path, publicKeyBlockType)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", path, ErrInvalidSigningKey)
+	}
+
+	return pub, nil
+}
+
+// KeyID returns a fingerprint identifying the given public key.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:])
+}
+
+// SignPackage signs the package at the given reference and pushes the signature to the same repository.
+func SignPackage(ref, keyPath string) error {
+	key, err := LoadSigningKey(keyPath)
+	if err != nil {
+		return err
+	}
+
+	registryStore, err := newRegistryStore()
+	if err != nil {
+		return err
+	}
+
+	return signPackage(context.Background(), registryStore, ref, key)
+}
+
+// VerifyPackage returns an error if the package at the given reference is not signed by one of the given public keys.
+func VerifyPackage(ref string, keyPaths ...string) error {
+	registryStore, err := newRegistryStore()
+	if err != nil {
+		return err
+	}
+
+	_, desc, err := registryStore.Resolve(context.Background(), ref)
+	if err != nil {
+		return err
+	}
+
+	return verifyPackage(context.Background(), registryStore, ref, desc, config.SignaturePolicy{
+		RequireSignatures: true,
+		TrustedKeys:       keyPaths,
+	})
+}
+
+func signPackage(ctx context.Context, store target.Target, ref string, key ed25519.PrivateKey) error {
+	_, desc, err := store.Resolve(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	sigRef, err := signatureRef(ref, desc.Digest)
+	if err != nil {
+		return err
+	}
+
+	keyID := KeyID(key.Public().(ed25519.PublicKey))
+	signature := Signature{
+		Digest:    desc.Digest.String(),
+		KeyID:     keyID,
+		Signature: ed25519.Sign(key, []byte(desc.Digest.String())),
+	}
+
+	// keep signatures made by other keys
+	existing, err := fetchSignatures(ctx, store, sigRef)
+	if err != nil {
+		logrus.Debugf("no existing signatures for %s: %s", ref, err)
+	}
+
+	signatures := []Signature{signature}
+	for _, s := range existing {
+		if s.KeyID != keyID {
+			signatures = append(signatures, s)
+		}
+	}
+
+	memoryStore := content.NewMemory()
+	configDescriptor, err := memoryStore.Add("", SignatureConfigMediaType, []byte("{}"))
+	if err != nil {
+		return err
+	}
+
+	var layers []v1.Descriptor
+	for _, s := range signatures {
+		buf, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+
+		layer, err := memoryStore.Add("", SignatureMediaType, buf)
+		if err != nil {
+			return err
+		}
+
+		layers = append(layers, layer)
+	}
+
+	annotations := map[string]string{SignedDigestAnnotation: desc.Digest.String()}
+	manifestData, manifestDescriptor, err := content.GenerateManifest(&configDescriptor, annotations, layers...)
+	if err != nil {
+		return err
+	}
+
+	if err = memoryStore.StoreManifest(sigRef, manifestDescriptor, manifestData); err != nil {
+		return err
+	}
+
+	logrus.Infof("pushing signature to %s", sigRef)
+	_, err = oras.Copy(ctx, memoryStore, sigRef, store, "")
+	return err
+}
+
+// verifyPackage checks the signatures of the package with the given descriptor against the policy.
+func verifyPackage(ctx context.Context, store target.Target, ref string, desc v1.Descriptor, policy config.SignaturePolicy) error {
+	_, err := verifyPackageKey(ctx, store, ref, desc, policy)
+	return err
+}
+
+// verifyPackageKey checks the signatures of the package against the policy and returns the id of the key that
+// verified it.  The id is empty if the policy does not check signatures or the package is not signed and signatures
+// are not required.
+func verifyPackageKey(ctx context.Context, store target.Target, ref string, desc v1.Descriptor, policy config.SignaturePolicy) (string, error) {
+	if !policy.RequireSignatures && len(policy.TrustedKeys) == 0 {
+		return "", nil
+	}
+
+	trusted, err := trustedKeys(policy)
+	if err != nil {
+		return "", err
+	}
+
+	sigRef, err := signatureRef(ref, desc.Digest)
+	if err != nil {
+		return "", err
+	}
+
+	signatures, err := fetchSignatures(ctx, store, sigRef)
+	if err != nil {
+		logrus.Debugf("failed to fetch signatures for %s: %s", ref, err)
+	}
+
+	if len(signatures) == 0 {
+		if policy.RequireSignatures {
+			return "", fmt.Errorf("%s: %w", ref, ErrUnsigned)
+		}
+
+		logrus.Warnf("package %s is not signed", ref)
+		return "", nil
+	}
+
+	for _, s := range signatures {
+		pub, ok := trusted[s.KeyID]
+		if !ok || s.Digest != desc.Digest.String() {
+			continue
+		}
+
+		if ed25519.Verify(pub, []byte(desc.Digest.String()), s.Signature) {
+			logrus.Debugf("package %s verified with key %s", ref, s.KeyID)
+			return s.KeyID, nil
+		}
+	}
+
+	return "", fmt.Errorf("%s: %w", ref, ErrUntrustedPackage)
+}
+
+// trustedKeys loads the trusted keys of the policy by key id.
+func trustedKeys(policy config.SignaturePolicy) (map[string]ed25519.PublicKey, error) {
+	if len(policy.TrustedKeys) == 0 {
+		return nil, ErrNoTrustedKeys
+	}
+
+	trusted := map[string]ed25519.PublicKey{}
+	for _, path := range policy.TrustedKeys {
+		pub, err := LoadVerificationKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load trusted key: %w", err)
+		}
+
+		trusted[KeyID(pub)] = pub
+	}
+
+	return trusted, nil
+}
+
+// fetchSignatures returns all signatures stored at the given signature reference.
+func fetchSignatures(ctx context.Context, store target.Target, sigRef string) ([]Signature, error) {
+	_, desc, err := store.Resolve(ctx, sigRef)
+	if err != nil {
+		return nil, err
+	}
+
+	fetcher, err := store.Fetcher(ctx, sigRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest v1.Manifest
+	if err = fetchJSON(ctx, fetcher.Fetch, desc, &manifest); err != nil {
+		return nil, err
+	}
+
+	var signatures []Signature
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != SignatureMediaType {
+			continue
+		}
+
+		var s Signature
+		if err = fetchJSON(ctx, fetcher.Fetch, layer, &s); err != nil {
+			return nil, err
+		}
+
+		signatures = append(signatures, s)
+	}
+
+	return signatures, nil
+}
+
+// signatureRef returns the tag signatures for the given digest are stored at.
+func signatureRef(ref string, d digest.Digest) (string, error) {
+	r, err := registry.ParseReference(ref)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s:%s-%s.sig", r.Registry, r.Repository, d.Algorithm(), d.Encoded()), nil
+}
+
+func fetchJSON(ctx context.Context, fetch func(context.Context, v1.Descriptor) (io.ReadCloser, error), desc v1.Descriptor, v any) error {
+	r, err := fetch(ctx, desc)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+
+	buf, err := io.ReadAll(newVerifiedReader(r, desc))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(buf, v)
+}
+
+func readPEM(path, blockType string) ([]byte, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(buf)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("%s: expected a PEM encoded %s", path, blockType)
+	}
+
+	return block.Bytes, nil
+}
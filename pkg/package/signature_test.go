@@ -0,0 +1,82 @@
+package _package
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancherlabs/corral/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"oras.land/oras-go/pkg/content"
+)
+
+func TestSignatures(t *testing.T) {
+	ctx := context.Background()
+	ref := "localhost:5000/rancher/test:latest"
+	unsignedRef := "localhost:5000/rancher/unsigned:latest"
+
+	// use a memory store in place of a registry
+	reg := content.NewMemory()
+	for _, r := range []string{ref, unsignedRef} {
+		configDescriptor, err := reg.Add("", "", []byte(r))
+		require.NoError(t, err)
+		manifest, desc, err := content.GenerateManifest(&configDescriptor, nil)
+		require.NoError(t, err)
+		require.NoError(t, reg.StoreManifest(r, desc, manifest))
+	}
+
+	dir := t.TempDir()
+	trustedKey := filepath.Join(dir, "trusted.key")
+	otherKey := filepath.Join(dir, "other.key")
+	require.NoError(t, GenerateSigningKey(trustedKey))
+	require.NoError(t, GenerateSigningKey(otherKey))
+
+	key, err := LoadSigningKey(otherKey)
+	require.NoError(t, err)
+	require.NoError(t, signPackage(ctx, reg, ref, key))
+
+	_, desc, err := reg.Resolve(ctx, ref)
+	require.NoError(t, err)
+	_, unsignedDesc, err := reg.Resolve(ctx, unsignedRef)
+	require.NoError(t, err)
+
+	trusted := config.SignaturePolicy{TrustedKeys: []string{trustedKey + ".pub"}}
+	required := config.SignaturePolicy{RequireSignatures: true, TrustedKeys: []string{trustedKey + ".pub"}}
+
+	{ // no policy
+		assert.NoError(t, verifyPackage(ctx, reg, unsignedRef, unsignedDesc, config.SignaturePolicy{}))
+	}
+
+	{ // required without keys
+		err := verifyPackage(ctx, reg, ref, desc, config.SignaturePolicy{RequireSignatures: true})
+		assert.ErrorIs(t, err, ErrNoTrustedKeys)
+	}
+
+	{ // signed by an untrusted key
+		assert.ErrorIs(t, verifyPackage(ctx, reg, ref, desc, trusted), ErrUntrustedPackage)
+	}
+
+	{ // unsigned
+		assert.NoError(t, verifyPackage(ctx, reg, unsignedRef, unsignedDesc, trusted))
+		assert.ErrorIs(t, verifyPackage(ctx, reg, unsignedRef, unsignedDesc, required), ErrUnsigned)
+	}
+
+	{ // signed by a trusted key, existing signatures are kept
+		key, err := LoadSigningKey(trustedKey)
+		require.NoError(t, err)
+		require.NoError(t, signPackage(ctx, reg, ref, key))
+
+		assert.NoError(t, verifyPackage(ctx, reg, ref, desc, required))
+
+		sigRef, err := signatureRef(ref, desc.Digest)
+		require.NoError(t, err)
+		signatures, err := fetchSignatures(ctx, reg, sigRef)
+		require.NoError(t, err)
+		assert.Len(t, signatures, 2)
+	}
+
+	{ // signature for a different digest
+		assert.ErrorIs(t, verifyPackage(ctx, reg, ref, unsignedDesc, required), ErrUnsigned)
+	}
+}
@@ -0,0 +1,386 @@
+package _package
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/rancherlabs/corral/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	gitSourcePrefix = "git::"
+
+	gitSourceKind     = "git"
+	archiveSourceKind = "archive"
+)
+
+var ErrInvalidSource = errors.New("invalid package source")
+
+var commitRegexp = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// sourceRef is a package source that is not a local directory or OCI reference, e.g.
+// git::https://github.com/rancherlabs/corral-packages.git//packages/k3s?ref=v1 or https://example.com/k3s.tgz.
+type sourceRef struct {
+	kind   string
+	url    string
+	ref    string
+	subdir string
+}
+
+// parseSourceRef returns the source for git repositories, archive URLs and local archives.  ok is false for any
+// other reference.
+func parseSourceRef(src string) (s sourceRef, ok bool) {
+	switch {
+	case strings.HasPrefix(src, gitSourcePrefix):
+		s.kind = gitSourceKind
+		src = src[len(gitSourcePrefix):]
+	case strings.HasPrefix(src, "https://") || strings.HasPrefix(src, "http://"):
+		s.kind = archiveSourceKind
+	case isArchivePath(src):
+		s.kind = archiveSourceKind
+	default:
+		return s, false
+	}
+
+	var query string
+	if i := strings.Index(src, "?"); i >= 0 {
+		src, query = src[:i], src[i+1:]
+	}
+
+	// a double slash after the scheme separates the subdirectory of the package
+	start := 0
+	if i := strings.Index(src, "://"); i >= 0 {
+		start = i + len("://")
+	}
+	if i := strings.Index(src[start:], "//"); i >= 0 {
+		src, s.subdir = src[:start+i], src[start+i+2:]
+	}
+
+	if s.kind == gitSourceKind {
+		values, err := url.ParseQuery(query)
+		if err == nil {
+			s.ref = values.Get("ref")
+			values.Del("ref")
+			query = values.Encode()
+		}
+	}
+
+	s.url = src
+	if query != "" {
+		s.url += "?" + query
+	}
+
+	return s, true
+}
+
+// String returns the source in the same format it is parsed from.
+func (s sourceRef) String() string {
+	src, query, _ := strings.Cut(s.url, "?")
+
+	if s.subdir != "" {
+		src += "//" + s.subdir
+	}
+
+	if s.ref != "" {
+		if query != "" {
+			query += "&"
+		}
+		query += "ref=" + url.QueryEscape(s.ref)
+	}
+
+	if query != "" {
+		src += "?" + query
+	}
+
+	if s.kind == gitSourceKind {
+		src = gitSourcePrefix + src
+	}
+
+	return src
+}
+
+func isArchivePath(path string) bool {
+	path, _, _ = strings.Cut(path, "//")
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar"} {
+		if strings.HasSuffix(path, ext) {
+			info, err := os.Stat(path)
+			return err == nil && info.Mode().IsRegular()
+		}
+	}
+
+	return false
+}
+
+// loadSourcePackage fetches a package from a git repository or archive into the package cache and loads it.
+func loadSourcePackage(s sourceRef, offline bool) (pkg Package, err error) {
+	var dest string
+	switch s.kind {
+	case gitSourceKind:
+		dest, s.ref, err = fetchGitSource(s, offline)
+	default:
+		dest, err = fetchArchiveSource(s, offline)
+	}
+	if err != nil {
+		return
+	}
+
+	root := packageRoot(dest, s.subdir)
+	if !isInside(dest, root) {
+		return pkg, fmt.Errorf("%w: %s", ErrUnsafePath, s.subdir)
+	}
+
+	touchCacheEntry(dest)
+
+	pkg, err = loadLocalPackage(root)
+	pkg.Reference = s.String()
+
+	return
+}
+
+// fetchGitSource checks out the commit the source refers to into the package cache and returns the path to the
+// checkout and the commit.
+func fetchGitSource(s sourceRef, offline bool) (string, string, error) {
+	cacheRoot := config.CorralRoot("cache", "sources", gitSourceKind)
+
+	commit := s.ref
+	if !commitRegexp.MatchString(commit) {
+		if offline {
+			return "", "", fmt.Errorf("%w: %s, only git sources pinned to a commit can be loaded offline", ErrNotCached, s)
+		}
+
+		var err error
+		if commit, err = resolveGitCommit(s.url, s.ref); err != nil {
+			return "", "", err
+		}
+	}
+
+	dest := filepath.Join(cacheRoot, commit)
+	if _, err := os.Stat(dest); err == nil {
+		return dest, commit, nil
+	}
+
+	if offline {
+		return "", "", fmt.Errorf("%w: %s", ErrNotCached, s)
+	}
+
+	if err := os.MkdirAll(cacheRoot, 0o700); err != nil {
+		return "", "", err
+	}
+
+	tmp, err := os.MkdirTemp(cacheRoot, "clone-")
+	if err != nil {
+		return "", "", err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	// fetch branches and tags by name since not every server allows fetching arbitrary commits
+	want := s.ref
+	if want == "" {
+		want = "HEAD"
+	}
+
+	logrus.Infof("fetching %s from %s", want, s.url)
+	if _, err = git(tmp, "init", "-q"); err != nil {
+		return "", "", err
+	}
+	if _, err = git(tmp, "fetch", "-q", "--depth", "1", "--", s.url, want); err != nil {
+		return "", "", err
+	}
+	if _, err = git(tmp, "-c", "advice.detachedHead=false", "checkout", "-q", "FETCH_HEAD"); err != nil {
+		return "", "", err
+	}
+
+	// the ref may have moved since it was resolved
+	if commit, err = git(tmp, "rev-parse", "HEAD"); err != nil {
+		return "", "", err
+	}
+
+	if err = os.RemoveAll(filepath.Join(tmp, ".git")); err != nil {
+		return "", "", err
+	}
+
+	dest = filepath.Join(cacheRoot, commit)
+	if err = os.Rename(tmp, dest); err != nil && !os.IsExist(err) {
+		return "", "", err
+	}
+
+	s.ref = commit
+	writeSourceRecord(dest, s.String())
+
+	return dest, commit, nil
+}
+
+// resolveGitCommit returns the commit a branch or tag points to.  The default branch is used if ref is empty.
+func resolveGitCommit(repository, ref string) (string, error) {
+	pattern := ref
+	if pattern == "" {
+		pattern = "HEAD"
+	}
+
+	out, err := git("", "ls-remote", "--", repository, pattern)
+	if err != nil {
+		return "", err
+	}
+
+	refs := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		commit, name, ok := strings.Cut(line, "\t")
+		if ok {
+			refs[name] = commit
+		}
+	}
+
+	// prefer the commit of annotated tags over the tag object itself
+	for _, name := range []string{"refs/tags/" + ref + "^{}", "refs/tags/" + ref, "refs/heads/" + ref, pattern} {
+		if commit, ok := refs[name]; ok {
+			return commit, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: ref %s not found in %s", ErrInvalidSource, pattern, repository)
+}
+
+func git(dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// fetchArchiveSource extracts the archive into the package cache keyed by the hash of its content and returns the
+// path it was extracted to.
+func fetchArchiveSource(s sourceRef, offline bool) (string, error) {
+	cacheRoot := config.CorralRoot("cache", "sources", archiveSourceKind)
+	if err := os.MkdirAll(cacheRoot, 0o700); err != nil {
+		return "", err
+	}
+
+	archive, err := os.CreateTemp(cacheRoot, "download-")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = archive.Close()
+		_ = os.Remove(archive.Name())
+	}()
+
+	r, err := openArchive(s.url, offline)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(archive, h), r)
+	_ = r.Close()
+	if err != nil {
+		return "", err
+	}
+
+	dest := filepath.Join(cacheRoot, hex.EncodeToString(h.Sum(nil)))
+	if _, err = os.Stat(dest); err == nil {
+		return dest, nil
+	}
+
+	if _, err = archive.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.MkdirTemp(cacheRoot, "extract-")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	// archives may or may not be compressed regardless of their extension
+	br := bufio.NewReader(archive)
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		err = extractLayer(tmp, br)
+	} else {
+		err = extractTar(tmp, br)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to extract %s: %w", s.url, err)
+	}
+
+	if err = os.Rename(tmp, dest); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+
+	writeSourceRecord(dest, s.String())
+
+	return dest, nil
+}
+
+func openArchive(src string, offline bool) (io.ReadCloser, error) {
+	if !strings.HasPrefix(src, "https://") && !strings.HasPrefix(src, "http://") {
+		return os.Open(src)
+	}
+
+	if offline {
+		return nil, fmt.Errorf("%w: %s, remote archives cannot be loaded offline", ErrNotCached, src)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, src, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", corralUserAgent)
+
+	logrus.Infof("downloading %s", src)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("failed to download %s: %s", src, res.Status)
+	}
+
+	return res.Body, nil
+}
+
+// packageRoot returns the directory of the package in an extracted source.  Archives often wrap their content in a
+// single top level directory which is skipped if the package is not at the root.
+func packageRoot(dir, subdir string) string {
+	root := filepath.Join(dir, subdir)
+	if _, err := os.Stat(filepath.Join(root, "manifest.yaml")); err == nil {
+		return root
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return root
+	}
+
+	return filepath.Join(dir, entries[0].Name(), subdir)
+}
+
+// writeSourceRecord records which source was fetched into dest for listing the cache.
+func writeSourceRecord(dest, source string) {
+	if err := os.WriteFile(dest+".source", []byte(source), 0o600); err != nil {
+		logrus.Debugf("failed to record source of %s: %s", dest, err)
+	}
+}
@@ -0,0 +1,141 @@
+package _package
+
+import (
+	"archive/tar"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rancherlabs/corral/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSourceRef(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "pkg.tgz")
+	require.NoError(t, os.WriteFile(archive, nil, 0o600))
+
+	tests := []struct {
+		src      string
+		expected sourceRef
+		ok       bool
+	}{
+		{
+			src:      "git::https://github.com/rancherlabs/corral-packages.git//packages/k3s?ref=v1",
+			expected: sourceRef{kind: gitSourceKind, url: "https://github.com/rancherlabs/corral-packages.git", ref: "v1", subdir: "packages/k3s"},
+			ok:       true,
+		},
+		{
+			src:      "git::ssh://git@github.com/rancherlabs/corral-packages.git",
+			expected: sourceRef{kind: gitSourceKind, url: "ssh://git@github.com/rancherlabs/corral-packages.git"},
+			ok:       true,
+		},
+		{
+			src:      "https://example.com/k3s.tgz//k3s?token=abc",
+			expected: sourceRef{kind: archiveSourceKind, url: "https://example.com/k3s.tgz?token=abc", subdir: "k3s"},
+			ok:       true,
+		},
+		{
+			src:      archive,
+			expected: sourceRef{kind: archiveSourceKind, url: archive},
+			ok:       true,
+		},
+		{src: "ghcr.io/rancher/k3s:latest"},
+		{src: "missing.tgz"},
+		{src: t.TempDir()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			s, ok := parseSourceRef(tt.src)
+			require.Equal(t, tt.ok, ok)
+
+			if ok {
+				assert.Equal(t, tt.expected, s)
+				assert.Equal(t, tt.src, s.String())
+			}
+		})
+	}
+}
+
+func TestLoadArchiveSource(t *testing.T) {
+	config.InitializeRootPath(t.TempDir())
+
+	manifest, err := os.ReadFile("tests/valid.yaml")
+	require.NoError(t, err)
+
+	archive := buildLayer(t,
+		tarEntry{name: "k3s-main/manifest.yaml", typeflag: tar.TypeReg, body: string(manifest)},
+		tarEntry{name: "k3s-main/overlay/README", typeflag: tar.TypeReg, body: "overlay"},
+	)
+
+	path := filepath.Join(t.TempDir(), "k3s.tar.gz")
+	require.NoError(t, os.WriteFile(path, archive, 0o600))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(archive)
+	}))
+	defer server.Close()
+
+	local, err := LoadPackage(path)
+	require.NoError(t, err)
+	assert.True(t, isInside(config.CorralRoot("cache", "sources", archiveSourceKind), local.RootPath))
+	assert.Equal(t, "k3s-main", filepath.Base(local.RootPath))
+	assert.Equal(t, path, local.PinnedReference())
+
+	remote, err := LoadPackage(server.URL + "/k3s.tgz")
+	require.NoError(t, err)
+	assert.Equal(t, local.RootPath, remote.RootPath)
+
+	_, err = LoadPackage(server.URL+"/k3s.tgz", WithOffline(true))
+	assert.ErrorIs(t, err, ErrNotCached)
+
+	cache, err := ListCache([]string{local.RootPath})
+	require.NoError(t, err)
+	require.Len(t, cache.Packages, 1)
+	assert.True(t, cache.Packages[0].Referenced)
+}
+
+func TestLoadGitSource(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	config.InitializeRootPath(t.TempDir())
+
+	repo := t.TempDir()
+	manifest, err := os.ReadFile("tests/valid.yaml")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "packages", "test"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "packages", "test", "manifest.yaml"), manifest, 0o600))
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=corral", "-c", "user.email=corral@example.com", "commit", "-q", "-m", "init"},
+		{"tag", "v1"},
+	} {
+		_, err = git(repo, args...)
+		require.NoError(t, err)
+	}
+
+	commit, err := git(repo, "rev-parse", "HEAD")
+	require.NoError(t, err)
+
+	pkg, err := LoadPackage("git::file://" + repo + "//packages/test?ref=v1")
+	require.NoError(t, err)
+	assert.Equal(t, config.CorralRoot("cache", "sources", gitSourceKind, commit, "packages", "test"), pkg.RootPath)
+	assert.True(t, strings.HasSuffix(pkg.PinnedReference(), "?ref="+commit))
+
+	// pinned commits load from the cache offline
+	pinned, err := LoadPackage(pkg.PinnedReference(), WithOffline(true))
+	require.NoError(t, err)
+	assert.Equal(t, pkg.RootPath, pinned.RootPath)
+
+	_, err = LoadPackage("git::file://"+repo+"//packages/test?ref=v1", WithOffline(true))
+	assert.ErrorIs(t, err, ErrNotCached)
+}
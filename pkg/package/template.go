@@ -1,18 +1,26 @@
 package _package
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 )
 
+const (
+	variableConflict = "variable"
+	fileConflict     = "file"
+)
+
 type TemplateManifest struct {
 	Name            string            `yaml:"name"`
 	Annotations     map[string]string `yaml:"annotations,omitempty"`
@@ -22,10 +30,86 @@ type TemplateManifest struct {
 	VariableSchemas map[string]any    `yaml:"variables,omitempty"`
 }
 
+// TemplateConfig describes a package rendered from existing packages.
+type TemplateConfig struct {
+	// Name is the directory the package is written to, the base of the path is the name of the package.
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description,omitempty"`
+	Packages    []string `yaml:"packages"`
+	// Variables are added to the rendered package and replace variables with the same name from the packages.
+	Variables map[string]any `yaml:"variables,omitempty"`
+	// Defaults override the default values of variables.
+	Defaults map[string]any `yaml:"defaults,omitempty"`
+	// NodePools renames node pools referenced by the commands and overlays of the packages.
+	NodePools map[string]string `yaml:"node_pools,omitempty"`
+}
+
+// Conflict is a variable schema or overlay file that more than one package defines differently.  The definition of
+// the last package is used.
+type Conflict struct {
+	Kind     string
+	Name     string
+	Packages []string
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s [%s] is defined differently by %s", c.Kind, c.Name, strings.Join(c.Packages, ", "))
+}
+
+// LoadTemplateConfig reads a template config file.  The relative name and package paths are resolved against the
+// directory of the config file.
+func LoadTemplateConfig(path string) (TemplateConfig, error) {
+	var cfg TemplateConfig
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	if err = yaml.Unmarshal(buf, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	if cfg.Name != "" && !filepath.IsAbs(cfg.Name) {
+		cfg.Name = filepath.Join(dir, cfg.Name)
+	}
+
+	for i, p := range cfg.Packages {
+		if filepath.IsAbs(p) {
+			continue
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, p)); err == nil {
+			cfg.Packages[i] = filepath.Join(dir, p)
+		}
+	}
+
+	return cfg, nil
+}
+
 func Template(name, description string, packages ...string) error {
-	pkgs := make([]Package, len(packages))
+	return RenderTemplate(TemplateConfig{
+		Name:        name,
+		Description: description,
+		Packages:    packages,
+	})
+}
+
+// RenderTemplate merges the packages of the template config into a new package.  Conflicts between the packages are
+// logged as warnings.
+func RenderTemplate(cfg TemplateConfig) error {
+	if cfg.Name == "" {
+		return errors.New("the rendered package must have a name")
+	}
+
+	if len(cfg.Packages) == 0 {
+		return errors.New("at least one package is required")
+	}
 
-	for i, p := range packages {
+	pkgs := make([]Package, len(cfg.Packages))
+
+	for i, p := range cfg.Packages {
 		pkg, err := LoadPackage(p) // ensures pkg is in cache
 		if err != nil {
 			return fmt.Errorf("failed to load [%s] package: %w", p, err)
@@ -33,29 +117,110 @@ func Template(name, description string, packages ...string) error {
 		pkgs[i] = pkg
 	}
 
-	manifest, err := MergePackages(name, description, pkgs)
+	if err := os.MkdirAll(cfg.Name, 0o700); err != nil {
+		return err
+	}
+
+	manifest, conflicts, err := MergePackages(cfg.Name, cfg.Description, pkgs)
 	if err != nil {
 		return err
 	}
 
+	if err = applyTemplateConfig(&manifest, cfg); err != nil {
+		return err
+	}
+
+	for _, c := range conflicts {
+		if c.Kind == variableConflict && cfg.Variables[c.Name] != nil {
+			continue // replaced by the config
+		}
+
+		logrus.Warn(c)
+	}
+
 	buf, err := yaml.Marshal(manifest)
 	if err != nil {
 		return err
 	}
 
-	err = os.WriteFile(filepath.Join(name, "manifest.yaml"), buf, 0664)
+	err = os.WriteFile(filepath.Join(cfg.Name, "manifest.yaml"), buf, 0664)
 	if err != nil {
 		return fmt.Errorf("failed to write manifest: %w", err)
 	}
 
-	err = ValidateManifest(buf)
+	rendered, err := ParseManifest(buf)
 	if err != nil {
 		return fmt.Errorf("rendered package is not a valid package: %w", err)
 	}
+
+	if err = rendered.ValidateDefaults(); err != nil {
+		return fmt.Errorf("rendered package has invalid defaults: %w", err)
+	}
+
+	return nil
+}
+
+// applyTemplateConfig adds the variables, defaults and node pool names of the config to the merged manifest.
+func applyTemplateConfig(t *TemplateManifest, cfg TemplateConfig) error {
+	for k, v := range cfg.Variables {
+		t.VariableSchemas[k] = v
+	}
+
+	for k, v := range cfg.Defaults {
+		schema, ok := t.VariableSchemas[k].(map[string]any)
+		if !ok {
+			return fmt.Errorf("default for [%s] given but no package defines the variable", k)
+		}
+
+		out := map[string]any{}
+		for sk, sv := range schema {
+			out[sk] = sv
+		}
+		out["default"] = v
+
+		t.VariableSchemas[k] = out
+	}
+
+	if len(cfg.NodePools) == 0 {
+		return nil
+	}
+
+	used := map[string]bool{}
+	for i, c := range t.Commands {
+		pools := make([]string, len(c.NodePoolNames))
+		for j, name := range c.NodePoolNames {
+			if renamed, ok := cfg.NodePools[name]; ok {
+				used[name] = true
+				name = renamed
+			}
+			pools[j] = name
+		}
+		t.Commands[i].NodePoolNames = pools
+	}
+
+	overlay := map[string]string{}
+	for name, path := range t.Overlay {
+		if renamed, ok := cfg.NodePools[name]; ok {
+			used[name] = true
+			name = renamed
+		}
+		overlay[name] = path
+	}
+	t.Overlay = overlay
+
+	for name := range cfg.NodePools {
+		if !used[name] {
+			logrus.Warnf("node pool [%s] is not used by any package", name)
+		}
+	}
+
 	return nil
 }
 
-func MergePackages(name, description string, pkgs []Package) (TemplateManifest, error) {
+// MergePackages merges the packages into a new package written to name.  Terraform modules are namespaced by package
+// name and the overlays and variables of later packages take precedence.  Variables and overlay files that are defined
+// differently by more than one package are returned as conflicts.
+func MergePackages(name, description string, pkgs []Package) (TemplateManifest, []Conflict, error) {
 	if description == "" {
 		for i := range pkgs {
 			if i > 0 {
@@ -75,10 +240,14 @@ func MergePackages(name, description string, pkgs []Package) (TemplateManifest,
 		VariableSchemas: map[string]any{},
 	}
 
+	var conflicts []Conflict
+	variableSources := map[string]string{}
+	written := map[string]string{}
+
 	for _, pkg := range pkgs {
 		buf, err := os.ReadFile(filepath.Join(pkg.RootPath, "manifest.yaml"))
 		if err != nil {
-			return t, err
+			return t, conflicts, err
 		}
 
 		yml := struct {
@@ -89,7 +258,7 @@ func MergePackages(name, description string, pkgs []Package) (TemplateManifest,
 
 		err = yaml.Unmarshal(buf, &yml)
 		if err != nil {
-			return t, err
+			return t, conflicts, err
 		}
 
 		for _, c := range pkg.Commands {
@@ -101,49 +270,72 @@ func MergePackages(name, description string, pkgs []Package) (TemplateManifest,
 		for k, v := range pkg.Overlay {
 			t.Overlay[k] = v
 		}
-		for k, v := range yml.VariableSchemas {
-			if _, ok := yml.VariableSchemas[k]; ok {
-				t.VariableSchemas[k] = mergeVariable(yml.VariableSchemas[k], v)
+		for _, k := range sortedKeys(yml.VariableSchemas) {
+			v := yml.VariableSchemas[k]
+			if existing, ok := t.VariableSchemas[k]; ok {
+				if !reflect.DeepEqual(existing, v) {
+					conflicts = append(conflicts, Conflict{
+						Kind:     variableConflict,
+						Name:     k,
+						Packages: []string{variableSources[k], pkg.Name},
+					})
+				}
+				t.VariableSchemas[k] = mergeVariable(existing, v)
 			} else {
 				t.VariableSchemas[k] = v
 			}
+			variableSources[k] = pkg.Name
 		}
 
 		logrus.Infof("Copying modules from %s", pkg.Name)
 
-		err = copyTerraform(name, pkg)
+		cs, err := copyTerraform(name, pkg, written)
 		if err != nil {
-			return t, err
+			return t, conflicts, err
 		}
+		conflicts = append(conflicts, cs...)
 
 		logrus.Infof("Copying overlay from %s", pkg.Name)
 
-		err = copyOverlay(name, pkg)
+		cs, err = copyOverlay(name, pkg, written)
 		if err != nil {
-			return t, err
+			return t, conflicts, err
 		}
+		conflicts = append(conflicts, cs...)
 	}
-	return t, nil
+	return t, conflicts, nil
 }
 
-func copyTerraform(root string, pkg Package) error {
-	return copyFiles(filepath.Join(root, "terraform", pkg.Name), pkg.TerraformModulePath(""), pkg)
+func copyTerraform(root string, pkg Package, written map[string]string) ([]Conflict, error) {
+	return copyFiles(filepath.Join(root, "terraform", pkg.Name), "terraform", pkg, written)
 }
 
-func copyOverlay(root string, pkg Package) error {
-	return copyFiles(filepath.Join(root, "overlay"), pkg.OverlayPath(), pkg)
+func copyOverlay(root string, pkg Package, written map[string]string) ([]Conflict, error) {
+	return copyFiles(filepath.Join(root, "overlay"), "overlay", pkg, written)
 }
 
-func copyFiles(root, dir string, pkg Package) error {
+// copyFiles copies the files in the package directory at prefix to root, skipping files ignored by the package.
+// written maps the files copied so far to the package they were copied from, files that are replaced with different
+// content are returned as conflicts.
+func copyFiles(root, prefix string, pkg Package, written map[string]string) ([]Conflict, error) {
+	dir := filepath.Join(pkg.RootPath, prefix)
 	_, err := os.Stat(dir)
 	if errors.Is(err, os.ErrNotExist) {
-		return nil
+		return nil, nil
+	}
+
+	ignore, err := loadIgnoreFile(pkg.RootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.MkdirAll(root, 0700); err != nil {
+		return nil, err
 	}
-	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
 
+	var conflicts []Conflict
+	err = walkPackageDir(dir, prefix, ignore, func(rel string, d fs.DirEntry) error {
+		path := filepath.Join(dir, rel)
 		orig := path[len(dir):]
 		destPath := root + orig
 
@@ -159,6 +351,15 @@ func copyFiles(root, dir string, pkg Package) error {
 			}
 			logrus.Debugf("%s: %s -> %s", pkg.Name, orig, destPath)
 
+			if previous, ok := written[destPath]; ok && !sameContent(path, destPath) {
+				conflicts = append(conflicts, Conflict{
+					Kind:     fileConflict,
+					Name:     filepath.ToSlash(filepath.Join(prefix, rel)),
+					Packages: []string{previous, pkg.Name},
+				})
+			}
+			written[destPath] = pkg.Name
+
 			if _, err = os.Stat(destPath); err == nil {
 				_ = os.Remove(destPath)
 			}
@@ -184,14 +385,43 @@ func copyFiles(root, dir string, pkg Package) error {
 
 		return nil
 	})
-	return err
+	return conflicts, err
 }
 
+// sameContent returns true if both files exist and have the same content.
+func sameContent(a, b string) bool {
+	bufA, err := os.ReadFile(a)
+	if err != nil {
+		return false
+	}
+
+	bufB, err := os.ReadFile(b)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(bufA, bufB)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// mergeVariable merges variable schemas, keys of later schemas replace keys of earlier schemas.
 func mergeVariable(vars ...any) any {
 	out := map[string]any{}
 
 	for _, v := range vars {
-		vm := v.(map[string]any)
+		vm, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
 
 		for k, v := range vm {
 			out[k] = v
@@ -199,5 +429,4 @@ func mergeVariable(vars ...any) any {
 	}
 
 	return out
-
 }
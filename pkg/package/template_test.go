@@ -0,0 +1,180 @@
+package _package
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePackagesConflicts(t *testing.T) {
+	root := t.TempDir()
+
+	writeTestPackage(t, filepath.Join(root, "a"), `
+name: a
+description: a
+commands:
+  - command: /opt/a.sh
+    node_pools: [all]
+variables:
+  token:
+    type: string
+    sensitive: true
+  count:
+    type: integer
+    description: from a
+`, map[string]string{
+		"overlay/opt/a.sh":   "a",
+		"overlay/opt/common": "same",
+		"overlay/opt/motd":   "a",
+	})
+
+	writeTestPackage(t, filepath.Join(root, "b"), `
+name: b
+description: b
+commands:
+  - command: /opt/b.sh
+    node_pools: [all]
+variables:
+  token:
+    type: string
+    sensitive: true
+  count:
+    type: integer
+    default: 2
+`, map[string]string{
+		"overlay/opt/b.sh":   "b",
+		"overlay/opt/common": "same",
+		"overlay/opt/motd":   "b",
+	})
+
+	var pkgs []Package
+	for _, name := range []string{"a", "b"} {
+		pkg, err := loadLocalPackage(filepath.Join(root, name))
+		require.NoError(t, err)
+		pkgs = append(pkgs, pkg)
+	}
+
+	out := filepath.Join(t.TempDir(), "merged")
+	manifest, conflicts, err := MergePackages(out, "", pkgs)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Conflict{
+		{Kind: variableConflict, Name: "count", Packages: []string{"a", "b"}},
+		{Kind: fileConflict, Name: "overlay/opt/motd", Packages: []string{"a", "b"}},
+	}, conflicts)
+
+	// keys of both schemas are kept
+	assert.Equal(t, map[string]any{
+		"type":        "integer",
+		"description": "from a",
+		"default":     2,
+	}, manifest.VariableSchemas["count"])
+
+	motd, err := os.ReadFile(filepath.Join(out, "overlay", "opt", "motd"))
+	require.NoError(t, err)
+	assert.Equal(t, "b", string(motd))
+}
+
+func TestRenderTemplateConfig(t *testing.T) {
+	root := t.TempDir()
+
+	writeTestPackage(t, filepath.Join(root, "packages", "simple"), `
+name: simple
+description: simple
+overlay:
+  all: common
+commands:
+  - module: main
+  - command: /app/setup.sh
+    node_pools: [all, bastion]
+variables:
+  var1:
+    type: string
+    default: foo
+`, map[string]string{
+		"terraform/main/main.tf":      "main",
+		"overlay/common/app/setup.sh": "setup",
+	})
+
+	config := filepath.Join(root, "config.yaml")
+	require.NoError(t, os.WriteFile(config, []byte(`
+name: rendered
+description: rendered from a config
+packages:
+  - packages/simple
+variables:
+  extra:
+    type: string
+    optional: true
+defaults:
+  var1: bar
+node_pools:
+  all: nodes
+`), 0o600))
+
+	cfg, err := LoadTemplateConfig(config)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(root, "packages", "simple")}, cfg.Packages)
+
+	cfg.Name = filepath.Join(t.TempDir(), "rendered")
+	require.NoError(t, RenderTemplate(cfg))
+
+	pkg, err := loadLocalPackage(cfg.Name)
+	require.NoError(t, err)
+
+	assert.Equal(t, "rendered", pkg.Name)
+	assert.Equal(t, "rendered from a config", pkg.Description)
+	assert.Equal(t, []Command{
+		{Module: "simple/main"},
+		{Command: "/app/setup.sh", NodePoolNames: []string{"nodes", "bastion"}},
+	}, pkg.Commands)
+	assert.Equal(t, map[string]string{"nodes": "common"}, pkg.Overlay)
+	assert.Equal(t, "bar", pkg.VariableSchemas["var1"].Default)
+	assert.Contains(t, pkg.VariableSchemas, "extra")
+	assert.FileExists(t, filepath.Join(pkg.OverlayPath(), "common", "app", "setup.sh"))
+
+	cfg.Defaults = map[string]any{"missing": "value"}
+	assert.Error(t, RenderTemplate(cfg))
+
+	cfg.Defaults = map[string]any{"var1": 1}
+	assert.Error(t, RenderTemplate(cfg))
+}
+
+func TestLoadTemplateConfigFromOtherDirectory(t *testing.T) {
+	root := t.TempDir()
+
+	writeTestPackage(t, filepath.Join(root, "examples", "simple"), `
+name: simple
+description: simple
+commands:
+  - module: main
+`, map[string]string{
+		"terraform/main/main.tf": "main",
+	})
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "examples", "template"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "examples", "template", "config.yaml"), []byte(`
+name: simple-test
+description: rendered from a config
+packages:
+  - ../simple
+`), 0o600))
+
+	// load the config relative to a working directory that is not the config's directory
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(root))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	cfg, err := LoadTemplateConfig(filepath.Join("examples", "template", "config.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("examples", "template", "simple-test"), cfg.Name)
+	assert.Equal(t, []string{filepath.Join("examples", "simple")}, cfg.Packages)
+
+	require.NoError(t, RenderTemplate(cfg))
+	assert.FileExists(t, filepath.Join(root, "examples", "template", "simple-test", "manifest.yaml"))
+	assert.NoDirExists(t, filepath.Join(root, "simple-test"))
+}
@@ -17,11 +17,33 @@ import (
 )
 
 func UploadPackage(pkg Package, ref string) error {
+	memoryStore, err := buildPackage(pkg, ref)
+	if err != nil {
+		return err
+	}
+
+	registryStore, err := newRegistryStore()
+	if err != nil {
+		return err
+	}
+
+	logrus.Info("pushing to registry")
+	_, err = oras.Copy(context.Background(), memoryStore, ref, registryStore, "")
+	return err
+}
+
+// buildPackage returns a memory store containing the manifest and layers of the package stored under ref.
+func buildPackage(pkg Package, ref string) (*content.Memory, error) {
 	logrus.Info("building manifest")
 	memoryStore := content.NewMemory()
 	configDescriptor, err := getManifestDescriptor(memoryStore, pkg)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	ignore, err := loadIgnoreFile(pkg.RootPath)
+	if err != nil {
+		return nil, err
 	}
 
 	logrus.Info("compressing package contents")
@@ -30,38 +52,31 @@ func UploadPackage(pkg Package, ref string) error {
 	if desc, err := addManifestLayer(memoryStore, pkg); err == nil {
 		contents = append(contents, desc)
 	} else {
-		return err
+		return nil, err
 	}
 
-	if ds, err := addTerraformModuleLayers(memoryStore, pkg); err == nil {
+	if ds, err := addTerraformModuleLayers(memoryStore, pkg, ignore); err == nil {
 		contents = append(contents, ds...)
 	} else {
-		return err
+		return nil, err
 	}
 
-	if desc, err := addOverlayLayer(memoryStore, pkg); err == nil {
+	if desc, err := addOverlayLayer(memoryStore, pkg, ignore); err == nil {
 		contents = append(contents, desc)
 	} else {
-		return err
+		return nil, err
 	}
 
 	manifestData, manifestDescriptor, err := content.GenerateManifest(&configDescriptor, pkg.Annotations, contents...)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if err = memoryStore.StoreManifest(ref, manifestDescriptor, manifestData); err != nil {
-		return err
-	}
-
-	registryStore, err := newRegistryStore()
-	if err != nil {
-		return err
+		return nil, err
 	}
 
-	logrus.Info("pushing to registry")
-	_, err = oras.Copy(context.Background(), memoryStore, ref, registryStore, "")
-	return err
+	return memoryStore, nil
 }
 
 func getManifestDescriptor(memoryStore *content.Memory, pkg Package) (v1.Descriptor, error) {
@@ -70,7 +85,7 @@ func getManifestDescriptor(memoryStore *content.Memory, pkg Package) (v1.Descrip
 		return v1.Descriptor{}, err
 	}
 
-	return memoryStore.Add("", v1.MediaTypeImageLayer, buf)
+	return memoryStore.Add("", ConfigMediaType, buf)
 }
 
 func addManifestLayer(memoryStore *content.Memory, pkg Package) (v1.Descriptor, error) {
@@ -106,21 +121,25 @@ func addManifestLayer(memoryStore *content.Memory, pkg Package) (v1.Descriptor,
 		return v1.Descriptor{}, err
 	}
 
-	return memoryStore.Add("", v1.MediaTypeImageLayerGzip, buf.Bytes())
+	return memoryStore.Add("manifest.yaml", ManifestLayerMediaType, buf.Bytes())
 }
 
-func addTerraformModuleLayers(memoryStore *content.Memory, pkg Package) ([]v1.Descriptor, error) {
+func addTerraformModuleLayers(memoryStore *content.Memory, pkg Package, ignore ignoreMatcher) ([]v1.Descriptor, error) {
 	var ds []v1.Descriptor
 
 	var desc v1.Descriptor
+	seen := map[string]bool{}
 	for _, cmd := range pkg.Commands {
-		if cmd.Module != "" {
-			buf, err := compressPath(filepath.Join("terraform", cmd.Module), pkg.TerraformModulePath(cmd.Module))
+		if cmd.Module != "" && !seen[cmd.Module] {
+			seen[cmd.Module] = true
+
+			prefix := filepath.Join("terraform", cmd.Module)
+			buf, err := compressPath(prefix, pkg.TerraformModulePath(cmd.Module), ignore)
 			if err != nil {
 				return nil, err
 			}
 
-			desc, err = memoryStore.Add("", v1.MediaTypeImageLayerGzip, buf)
+			desc, err = memoryStore.Add(filepath.ToSlash(prefix), TerraformLayerMediaType, buf)
 			if err != nil {
 				return nil, err
 			}
@@ -132,36 +151,29 @@ func addTerraformModuleLayers(memoryStore *content.Memory, pkg Package) ([]v1.De
 	return ds, nil
 }
 
-func addOverlayLayer(memoryStore *content.Memory, pkg Package) (v1.Descriptor, error) {
-	buf, err := compressPath("overlay", pkg.OverlayPath())
+func addOverlayLayer(memoryStore *content.Memory, pkg Package, ignore ignoreMatcher) (v1.Descriptor, error) {
+	buf, err := compressPath("overlay", pkg.OverlayPath(), ignore)
 	if err != nil {
 		return v1.Descriptor{}, err
 	}
 
-	return memoryStore.Add("", v1.MediaTypeImageLayerGzip, buf)
+	return memoryStore.Add("overlay", OverlayLayerMediaType, buf)
 }
 
-func compressPath(prefix, root string) ([]byte, error) {
+// compressPath returns a gzipped tar of the files under root that are not ignored.  Files are stored under prefix,
+// which is also the path of root relative to the package root.
+func compressPath(prefix, root string, ignore ignoreMatcher) ([]byte, error) {
 	var buf bytes.Buffer
 	gz := gzip.NewWriter(&buf)
 	tw := tar.NewWriter(gz)
 
-	sfs := os.DirFS(root)
-	err := fs.WalkDir(sfs, ".", func(path string, d fs.DirEntry, err error) error {
-		if path == "." {
-			return nil
-		}
-
-		if err != nil {
-			return err
-		}
-
+	err := walkPackageDir(root, prefix, ignore, func(path string, d fs.DirEntry) error {
 		if d.IsDir() {
 			hdr := &tar.Header{
 				Name:     filepath.Join(prefix, path),
 				Typeflag: tar.TypeDir,
 			}
-			if err = tw.WriteHeader(hdr); err != nil {
+			if err := tw.WriteHeader(hdr); err != nil {
 				return err
 			}
 			return nil
@@ -0,0 +1,122 @@
+package _package
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/rancherlabs/corral/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"oras.land/oras-go/pkg/content"
+)
+
+// storedManifest returns the image manifest stored under ref.
+func storedManifest(t *testing.T, memoryStore *content.Memory, ref string) v1.Manifest {
+	t.Helper()
+
+	_, desc, err := memoryStore.Resolve(context.Background(), ref)
+	require.NoError(t, err)
+
+	_, buf, ok := memoryStore.Get(desc)
+	require.True(t, ok)
+
+	var manifest v1.Manifest
+	require.NoError(t, json.Unmarshal(buf, &manifest))
+
+	return manifest
+}
+
+func TestBuildPackageMediaTypes(t *testing.T) {
+	ref := "localhost:5000/rancher/valid:v1.0.0"
+	config.InitializeRootPath(t.TempDir())
+
+	pkg := writePackageFiles(t, "terraform/module/main.tf", "overlay/README.md")
+
+	memoryStore, err := buildPackage(pkg, ref)
+	require.NoError(t, err)
+
+	manifest := storedManifest(t, memoryStore, ref)
+	assert.Equal(t, ConfigMediaType, manifest.Config.MediaType)
+
+	titles := map[string]string{}
+	for _, layer := range manifest.Layers {
+		titles[layer.Annotations[v1.AnnotationTitle]] = layer.MediaType
+	}
+	assert.Equal(t, map[string]string{
+		"manifest.yaml":    ManifestLayerMediaType,
+		"terraform/module": TerraformLayerMediaType,
+		"overlay":          OverlayLayerMediaType,
+	}, titles)
+
+	fetcher, err := memoryStore.Fetcher(context.Background(), ref)
+	require.NoError(t, err)
+
+	buf, err := fetchPackageManifest(fetcher, manifest)
+	require.NoError(t, err)
+
+	expected, err := os.ReadFile(pkg.ManifestPath())
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(buf))
+
+	loaded, err := pullPackage(context.Background(), memoryStore, ref, config.SignaturePolicy{})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(loaded.RootPath, "terraform", "module", "main.tf"))
+	assert.FileExists(t, filepath.Join(loaded.RootPath, "overlay", "README.md"))
+}
+
+func TestPullLegacyPackage(t *testing.T) {
+	ref := "localhost:5000/rancher/legacy:v1.0.0"
+	config.InitializeRootPath(t.TempDir())
+
+	pkg := writePackageFiles(t, "terraform/module/main.tf", "overlay/README.md")
+	ignore, err := loadIgnoreFile(pkg.RootPath)
+	require.NoError(t, err)
+
+	// packages published by older versions of corral use generic media types
+	memoryStore := content.NewMemory()
+	manifest, err := os.ReadFile(pkg.ManifestPath())
+	require.NoError(t, err)
+	configDesc, err := memoryStore.Add("", v1.MediaTypeImageLayer, manifest)
+	require.NoError(t, err)
+
+	var layers []v1.Descriptor
+	for _, prefix := range []string{"terraform/module", "overlay"} {
+		buf, err := compressPath(prefix, filepath.Join(pkg.RootPath, prefix), ignore)
+		require.NoError(t, err)
+		layer, err := memoryStore.Add("", v1.MediaTypeImageLayerGzip, buf)
+		require.NoError(t, err)
+		layers = append(layers, layer)
+	}
+
+	manifestLayer, err := memoryStore.Add("", v1.MediaTypeImageLayerGzip,
+		buildLayer(t, tarEntry{name: "manifest.yaml", typeflag: tar.TypeReg, body: string(manifest)}))
+	require.NoError(t, err)
+	layers = append(layers, manifestLayer)
+
+	// layers added by newer versions of corral are skipped
+	unknown, err := memoryStore.Add("", "application/vnd.cattle.corral.unknown.v1", []byte("not a tar"))
+	require.NoError(t, err)
+	layers = append(layers, unknown)
+
+	manifestData, manifestDesc, err := content.GenerateManifest(&configDesc, nil, layers...)
+	require.NoError(t, err)
+	require.NoError(t, memoryStore.StoreManifest(ref, manifestDesc, manifestData))
+
+	fetcher, err := memoryStore.Fetcher(context.Background(), ref)
+	require.NoError(t, err)
+
+	buf, err := fetchPackageManifest(fetcher, storedManifest(t, memoryStore, ref))
+	require.NoError(t, err)
+	assert.Equal(t, string(manifest), string(buf))
+
+	loaded, err := pullPackage(context.Background(), memoryStore, ref, config.SignaturePolicy{})
+	require.NoError(t, err)
+	assert.Equal(t, pkg.Name, loaded.Name)
+	assert.FileExists(t, filepath.Join(loaded.RootPath, "terraform", "module", "main.tf"))
+	assert.FileExists(t, filepath.Join(loaded.RootPath, "overlay", "README.md"))
+}
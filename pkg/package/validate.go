@@ -2,36 +2,343 @@ package _package
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/sirupsen/logrus"
+	"github.com/zclconf/go-cty/cty"
 )
 
-var ErrOverlayNotFound = errors.New("overlay folder not found")
+var (
+	ErrOverlayNotFound = errors.New("overlay folder not found")
+	ErrModuleNotFound  = errors.New("terraform module not found")
+)
+
+// corralVariables are set by corral for every module.
+var corralVariables = []string{
+	"corral_name",
+	"corral_user_id",
+	"corral_user_public_key",
+	"corral_public_key",
+	"corral_private_key",
+	"corral_ssh_key_type",
+	"corral_node_pools",
+}
+
+const nodePoolsOutput = "corral_node_pools"
+
+type ValidationLevel string
+
+const (
+	ValidationError   ValidationLevel = "error"
+	ValidationWarning ValidationLevel = "warning"
+)
+
+// ValidationResult is a problem found in a package.  Path is relative to the package root and may include a line
+// number.
+type ValidationResult struct {
+	Level   ValidationLevel `json:"level" yaml:"level"`
+	Path    string          `json:"path,omitempty" yaml:"path,omitempty"`
+	Message string          `json:"message" yaml:"message"`
+
+	err error
+}
+
+func (r ValidationResult) String() string {
+	if r.Path == "" {
+		return r.Message
+	}
+
+	return r.Path + ": " + r.Message
+}
+
+type ValidationResults []ValidationResult
 
+// Err joins the errors of the results, warnings are ignored.
+func (rs ValidationResults) Err() error {
+	var errs []error
+	for _, r := range rs {
+		if r.Level != ValidationError {
+			continue
+		}
+
+		if r.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Path, r.err))
+		} else {
+			errs = append(errs, errors.New(r.String()))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (rs *ValidationResults) add(level ValidationLevel, path, format string, args ...any) {
+	*rs = append(*rs, ValidationResult{Level: level, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (rs *ValidationResults) addErr(path string, err error) {
+	*rs = append(*rs, ValidationResult{Level: ValidationError, Path: path, Message: err.Error(), err: err})
+}
+
+// Validate loads the given package and returns an error if it fails any check of ValidatePackage.
 func Validate(name string) error {
 	pkg, err := LoadPackage(name)
 	if err != nil {
 		return err
 	}
 
+	results := ValidatePackage(pkg)
+	for _, r := range results {
+		if r.Level == ValidationWarning {
+			logrus.Warn(r)
+		}
+	}
+
+	if err = results.Err(); err != nil {
+		return err
+	}
+
+	logrus.Info("package is valid")
+	return nil
+}
+
+// ValidatePackage cross checks the manifest of the package with its overlay and terraform modules.
+func ValidatePackage(pkg Package) ValidationResults {
+	results := ValidationResults{}
+
 	if i, err := os.Stat(pkg.OverlayPath()); err != nil || !i.IsDir() {
-		return ErrOverlayNotFound
+		results.addErr("overlay", ErrOverlayNotFound)
+	} else {
+		for _, pool := range sortedKeys(pkg.Overlay) {
+			sub := pkg.Overlay[pool]
+			if i, err := os.Stat(filepath.Join(pkg.OverlayPath(), sub)); err != nil || !i.IsDir() {
+				results.add(ValidationError, "manifest.yaml", "overlay for node pool [%s] points to missing directory overlay/%s", pool, sub)
+			}
+		}
 	}
 
+	for _, name := range sortedKeys(pkg.VariableSchemas) {
+		s := pkg.VariableSchemas[name]
+		if s.Default != nil {
+			if err := s.Validate(s.Default); err != nil {
+				results.add(ValidationError, "manifest.yaml", "default of variable [%s] is invalid: %s", name, err)
+			}
+		}
+
+		if s.ReadOnly && s.required {
+			results.add(ValidationError, "manifest.yaml", "variable [%s] is readOnly but optional is false, users cannot set read only variables", name)
+		}
+	}
+
+	known := map[string]bool{}
+	for _, v := range corralVariables {
+		known[v] = true
+	}
+	for name := range pkg.VariableSchemas {
+		known[name] = true
+	}
+
+	// node pools is nil if any module returns node pools that cannot be determined statically
+	nodePools := map[string]bool{}
+	seen := map[string]bool{}
 	for _, cmd := range pkg.Commands {
-		if cmd.Module != "" {
-			if i, err := os.Stat(pkg.TerraformModulePath(cmd.Module)); err != nil || !i.IsDir() {
-				return err
+		if cmd.Module == "" || seen[cmd.Module] {
+			continue
+		}
+		seen[cmd.Module] = true
+
+		rel := filepath.ToSlash(filepath.Join("terraform", cmd.Module))
+		if i, err := os.Stat(pkg.TerraformModulePath(cmd.Module)); err != nil || !i.IsDir() {
+			results.addErr(rel, ErrModuleNotFound)
+			continue
+		}
+
+		mod, diags := loadTerraformModule(pkg.TerraformModulePath(cmd.Module))
+		for _, d := range diags {
+			results.add(ValidationError, diagnosticPath(pkg.RootPath, d), "%s: %s", d.Summary, d.Detail)
+		}
+
+		for _, v := range mod.variables {
+			if v.required && !known[v.name] {
+				results.add(ValidationError, rangePath(pkg.RootPath, v.rng), "terraform variable [%s] is required but is not a manifest variable, a corral variable or an output of a previous module", v.name)
+			}
+		}
+
+		// outputs are added to the corral's variables for the following modules
+		for name := range mod.outputs {
+			known[name] = true
+		}
+
+		if nodePools != nil {
+			if mod.nodePools == nil && mod.outputs[nodePoolsOutput] {
+				nodePools = nil
+			}
+			for pool := range mod.nodePools {
+				nodePools[pool] = true
 			}
 		}
 	}
 
-	err = pkg.ValidateDefaults()
+	if nodePools != nil {
+		for i, cmd := range pkg.Commands {
+			for _, pool := range cmd.NodePoolNames {
+				if !nodePools[pool] {
+					results.add(ValidationWarning, "manifest.yaml", "command %d [%s] targets node pool [%s] which is not returned by any module", i+1, cmd.Command, pool)
+				}
+			}
+		}
+
+		for _, pool := range sortedKeys(pkg.Overlay) {
+			if !nodePools[pool] {
+				results.add(ValidationWarning, "manifest.yaml", "overlay targets node pool [%s] which is not returned by any module", pool)
+			}
+		}
+	}
+
+	return results
+}
+
+type terraformVariable struct {
+	name     string
+	required bool
+	rng      hcl.Range
+}
+
+type terraformModule struct {
+	variables []terraformVariable
+	outputs   map[string]bool
+	// nodePools are the node pools of the corral_node_pools output, nil if the output cannot be read statically.
+	nodePools map[string]bool
+}
+
+var terraformFileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "variable", LabelNames: []string{"name"}},
+		{Type: "output", LabelNames: []string{"name"}},
+	},
+}
+
+// loadTerraformModule reads the variables and outputs declared by the terraform files in dir.
+func loadTerraformModule(dir string) (terraformModule, hcl.Diagnostics) {
+	mod := terraformModule{outputs: map[string]bool{}}
+
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return err
+		return mod, hcl.Diagnostics{{Severity: hcl.DiagError, Summary: "Failed to read module", Detail: err.Error(), Subject: &hcl.Range{Filename: dir}}}
 	}
 
-	logrus.Info("package is valid")
-	return nil
+	var diags hcl.Diagnostics
+	parser := hclparse.NewParser()
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		var f *hcl.File
+		var fileDiags hcl.Diagnostics
+
+		p := filepath.Join(dir, e.Name())
+		switch {
+		case strings.HasSuffix(e.Name(), ".tf"):
+			f, fileDiags = parser.ParseHCLFile(p)
+		case strings.HasSuffix(e.Name(), ".tf.json"):
+			f, fileDiags = parser.ParseJSONFile(p)
+		default:
+			continue
+		}
+		diags = append(diags, fileDiags...)
+		if f == nil {
+			continue
+		}
+
+		content, _, contentDiags := f.Body.PartialContent(terraformFileSchema)
+		diags = append(diags, contentDiags...)
+
+		for _, block := range content.Blocks {
+			name := block.Labels[0]
+
+			switch block.Type {
+			case "variable":
+				attrs, _, _ := block.Body.PartialContent(&hcl.BodySchema{Attributes: []hcl.AttributeSchema{{Name: "default"}}})
+				_, hasDefault := attrs.Attributes["default"]
+				mod.variables = append(mod.variables, terraformVariable{
+					name:     name,
+					required: !hasDefault,
+					rng:      block.DefRange,
+				})
+			case "output":
+				mod.outputs[name] = true
+				if name == nodePoolsOutput {
+					attrs, _, _ := block.Body.PartialContent(&hcl.BodySchema{Attributes: []hcl.AttributeSchema{{Name: "value"}}})
+					if value, ok := attrs.Attributes["value"]; ok {
+						mod.nodePools = staticNodePools(value.Expr)
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(mod.variables, func(i, j int) bool { return mod.variables[i].name < mod.variables[j].name })
+
+	var errs hcl.Diagnostics
+	for _, d := range diags {
+		if d.Severity == hcl.DiagError {
+			errs = append(errs, d)
+		}
+	}
+
+	return mod, errs
+}
+
+// staticNodePools returns the keys of an object expression or nil if they cannot be determined without applying the
+// module.
+func staticNodePools(expr hcl.Expression) map[string]bool {
+	pairs, diags := hcl.ExprMap(expr)
+	if diags.HasErrors() {
+		return nil
+	}
+
+	pools := map[string]bool{}
+	for _, pair := range pairs {
+		if kw := hcl.ExprAsKeyword(pair.Key); kw != "" {
+			pools[kw] = true
+			continue
+		}
+
+		v, diags := pair.Key.Value(nil)
+		if diags.HasErrors() || v.Type() != cty.String || !v.IsKnown() || v.IsNull() {
+			return nil
+		}
+		pools[v.AsString()] = true
+	}
+
+	return pools
+}
+
+func diagnosticPath(root string, d *hcl.Diagnostic) string {
+	if d.Subject == nil {
+		return ""
+	}
+
+	return rangePath(root, *d.Subject)
+}
+
+// rangePath formats the start of a range in a package file as path:line.
+func rangePath(root string, rng hcl.Range) string {
+	rel, err := filepath.Rel(root, rng.Filename)
+	if err != nil {
+		rel = rng.Filename
+	}
+	rel = filepath.ToSlash(rel)
+
+	if rng.Start.Line == 0 {
+		return rel
+	}
+
+	return fmt.Sprintf("%s:%d", rel, rng.Start.Line)
 }
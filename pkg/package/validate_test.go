@@ -0,0 +1,128 @@
+package _package
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePackage(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "pkg")
+
+	writeTestPackage(t, dir, `
+name: pkg
+description: pkg
+overlay:
+  all: all
+  missing: missing
+commands:
+  - module: first
+  - command: /opt/run.sh
+    node_pools: [all, other]
+  - module: second
+variables:
+  token:
+    type: string
+  host:
+    type: string
+    readOnly: true
+    optional: false
+`, map[string]string{
+		"overlay/all/opt/run.sh": "",
+		"terraform/first/main.tf": `
+variable "corral_name" {}
+variable "token" {}
+variable "region" {}
+variable "size" {
+  default = "small"
+}
+
+output "address" {
+  value = "127.0.0.1"
+}
+
+output "corral_node_pools" {
+  value = {
+    all     = []
+    "quoted" = []
+  }
+}
+`,
+		"terraform/second/main.tf": `
+variable "address" {}
+`,
+	})
+
+	pkg, err := loadLocalPackage(dir)
+	require.NoError(t, err)
+
+	results := ValidatePackage(pkg)
+	assert.Equal(t, ValidationResults{
+		{Level: ValidationError, Path: "manifest.yaml", Message: "overlay for node pool [missing] points to missing directory overlay/missing"},
+		{Level: ValidationError, Path: "manifest.yaml", Message: "variable [host] is readOnly but optional is false, users cannot set read only variables"},
+		{Level: ValidationError, Path: "terraform/first/main.tf:4", Message: "terraform variable [region] is required but is not a manifest variable, a corral variable or an output of a previous module"},
+		{Level: ValidationWarning, Path: "manifest.yaml", Message: "command 2 [/opt/run.sh] targets node pool [other] which is not returned by any module"},
+		{Level: ValidationWarning, Path: "manifest.yaml", Message: "overlay targets node pool [missing] which is not returned by any module"},
+	}, results)
+
+	err = results.Err()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "region")
+	assert.NotContains(t, err.Error(), "other")
+}
+
+func TestValidatePackageDynamicNodePools(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "pkg")
+
+	writeTestPackage(t, dir, `
+name: pkg
+description: pkg
+commands:
+  - module: main
+  - command: /opt/run.sh
+    node_pools: [anything]
+`, map[string]string{
+		"overlay/opt/run.sh": "",
+		"terraform/main/main.tf": `
+variable "pools" {
+  default = {}
+}
+
+output "corral_node_pools" {
+  value = var.pools
+}
+`,
+	})
+
+	pkg, err := loadLocalPackage(dir)
+	require.NoError(t, err)
+
+	assert.Empty(t, ValidatePackage(pkg))
+}
+
+func TestValidatePackageErrors(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "pkg")
+
+	writeTestPackage(t, dir, `
+name: pkg
+description: pkg
+commands:
+  - module: missing
+  - module: broken
+`, map[string]string{
+		"terraform/broken/main.tf": `locals {`,
+	})
+
+	pkg, err := loadLocalPackage(dir)
+	require.NoError(t, err)
+
+	results := ValidatePackage(pkg)
+	require.Len(t, results, 3)
+	assert.Equal(t, "terraform/broken/main.tf:1", results[2].Path)
+
+	err = results.Err()
+	assert.ErrorIs(t, err, ErrOverlayNotFound)
+	assert.ErrorIs(t, err, ErrModuleNotFound)
+}
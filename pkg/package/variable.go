@@ -1,6 +1,8 @@
 package _package
 
 import (
+	"encoding/json"
+
 	"github.com/santhosh-tekuri/jsonschema/v5"
 )
 
@@ -11,4 +13,29 @@ type Schema struct {
 	Optional    bool
 	Description string
 	Default     any
+
+	// required is true if optional is explicitly false
+	required bool
+}
+
+// Required returns true if the variable must be set by the user.
+func (s Schema) Required() bool {
+	return !s.Optional && !s.ReadOnly && s.Default == nil
+}
+
+// Parse parses and validates a value entered by a user.  Values of string variables are used as they are, other values
+// are parsed as json and fall back to a string.
+func (s Schema) Parse(raw string) (any, error) {
+	var v any = raw
+	if len(s.Types) != 1 || s.Types[0] != "string" {
+		if err := json.Unmarshal([]byte(raw), &v); err != nil {
+			v = raw
+		}
+	}
+
+	if err := s.Validate(v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
 }
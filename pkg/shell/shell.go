@@ -34,6 +34,8 @@ type Shell struct {
 	Node       corral.Node
 	PrivateKey []byte
 	Vars       vars.VarSet
+	// Outputs are the variables set with corral_set by the last command.
+	Outputs vars.VarSet
 
 	sftpClient    *sftp.Client
 	bastionClient *ssh.Client
@@ -163,6 +165,8 @@ func (s *Shell) Run(c string) error {
 		return err
 	}
 
+	s.Outputs = vars.VarSet{}
+
 	stdout, _ := session.StdoutPipe()
 	stderr, _ := session.StderrPipe()
 
@@ -233,6 +237,10 @@ func (s *Shell) Close() {
 }
 
 func (s *Shell) consumeStdout(pipe io.Reader) {
+	if s.Outputs == nil {
+		s.Outputs = vars.VarSet{}
+	}
+
 	scanner := bufio.NewScanner(pipe)
 
 	for scanner.Scan() {
@@ -252,6 +260,7 @@ func (s *Shell) consumeStdout(pipe io.Reader) {
 			}
 
 			s.Vars[k] = v
+			s.Outputs[k] = v
 		} else if strings.HasPrefix(text, corralLogMessageCommand) {
 			vs := strings.TrimPrefix(text, corralLogMessageCommand)
 			vs = strings.Trim(vs, " \t")
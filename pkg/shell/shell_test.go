@@ -157,6 +157,7 @@ func TestConsumeStdout(t *testing.T) {
 			b.WriteString(fmt.Sprintf("corral_set test=%s\n", tt.input))
 			s.consumeStdout(&b)
 			assert.DeepEqual(t, s.Vars["test"], tt.expected)
+			assert.DeepEqual(t, s.Outputs["test"], tt.expected)
 		})
 	}
 }
@@ -0,0 +1,55 @@
+package vars
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of environment variables that set corral variables, e.g. CORRAL_VAR_node_count=3.
+const EnvPrefix = "CORRAL_VAR_"
+
+// ReadFile reads the variables from a yaml or json file.  The file must contain a single object.
+func ReadFile(path string) (VarSet, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// decode into a plain map, nested objects would be decoded as VarSets
+	var vs map[string]any
+	if err = yaml.Unmarshal(body, &vs); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", path)
+	}
+	if vs == nil {
+		vs = map[string]any{}
+	}
+
+	return vs, nil
+}
+
+// FromEnv returns the variables set by the environment variables starting with EnvPrefix.  Values are parsed the same
+// way as variable flags.
+func FromEnv(environ []string) (VarSet, error) {
+	vs := VarSet{}
+	for _, env := range environ {
+		if !strings.HasPrefix(env, EnvPrefix) {
+			continue
+		}
+
+		k, v, err := ToVar(strings.TrimPrefix(env, EnvPrefix))
+		if err != nil {
+			name, _, _ := strings.Cut(env, "=")
+			return nil, errors.Wrapf(err, "environment variable %s", name)
+		}
+		if k == "" || v == nil {
+			continue
+		}
+
+		vs[k] = v
+	}
+
+	return vs, nil
+}
@@ -0,0 +1,77 @@
+package vars
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		body    string
+		want    VarSet
+		wantErr bool
+	}{
+		{
+			name: "yaml",
+			body: "node_count: 3\nregion: us-east-1\ntags:\n  - a\n  - b\n",
+			want: VarSet{"node_count": 3, "region": "us-east-1", "tags": []any{"a", "b"}},
+		},
+		{
+			name: "json",
+			body: `{"node_count": 3, "region": "us-east-1", "labels": {"a": "b"}}`,
+			want: VarSet{"node_count": 3, "region": "us-east-1", "labels": map[string]any{"a": "b"}},
+		},
+		{
+			name: "empty",
+			body: "",
+			want: VarSet{},
+		},
+		{
+			name:    "not an object",
+			body:    "- a\n- b\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.body), 0o600))
+
+			got, err := ReadFile(path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := ReadFile(filepath.Join(dir, "missing.yaml"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestFromEnv(t *testing.T) {
+	vs, err := FromEnv([]string{
+		"HOME=/root",
+		"CORRAL_VAR_node_count=3",
+		"CORRAL_VAR_region=us-east-1",
+		"CORRAL_VAR_tags=[\"a\",\"b\"]",
+		"CORRAL_VAR_empty=",
+		"CORRAL_VARIABLE=ignored",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, VarSet{"node_count": 3., "region": "us-east-1", "tags": []any{"a", "b"}}, vs)
+
+	_, err = FromEnv([]string{"CORRAL_VAR_bad={"})
+	assert.ErrorContains(t, err, "environment variable CORRAL_VAR_bad")
+}
@@ -0,0 +1,108 @@
+package vars
+
+import (
+	"sort"
+)
+
+// OriginKind is the kind of source a variable's value came from.
+type OriginKind string
+
+const (
+	OriginDefault OriginKind = "default"
+	OriginGlobal  OriginKind = "global"
+	OriginProfile OriginKind = "profile"
+	OriginLock    OriginKind = "lock"
+	OriginFile    OriginKind = "file"
+	OriginEnv     OriginKind = "env"
+	OriginFlag    OriginKind = "flag"
+	// OriginPrompt values were entered at a prompt for missing required variables.
+	OriginPrompt OriginKind = "prompt"
+
+	// OriginTerraform values are outputs of a terraform module.
+	OriginTerraform OriginKind = "terraform"
+	// OriginNode values were set with corral_set by a command running on a node.
+	OriginNode OriginKind = "node"
+	// OriginCorral values are generated by corral, e.g. ssh keys and the corral's name.
+	OriginCorral OriginKind = "corral"
+)
+
+// Precedence lists the kinds of user provided variables from lowest to highest precedence.
+var Precedence = []OriginKind{
+	OriginDefault,
+	OriginGlobal,
+	OriginProfile,
+	OriginLock,
+	OriginFile,
+	OriginEnv,
+	OriginFlag,
+	OriginPrompt,
+}
+
+// Origin records where a variable's value came from.  Source names the profile, file, module or node.
+type Origin struct {
+	Kind   OriginKind `yaml:"kind" json:"kind"`
+	Source string     `yaml:"source,omitempty" json:"source,omitempty"`
+}
+
+func (o Origin) String() string {
+	if o.Source == "" {
+		return string(o.Kind)
+	}
+
+	return string(o.Kind) + " (" + o.Source + ")"
+}
+
+// Origins are the origins of a VarSet's values by variable name.
+type Origins map[string]Origin
+
+// Layer is a set of variables from a single source.
+type Layer struct {
+	Origin Origin
+	Vars   VarSet
+}
+
+// Resolver merges layers of variables.  Layers are applied in the order of their kind's precedence and layers of the
+// same kind in the order they were added, so later files override earlier files.
+type Resolver struct {
+	layers []Layer
+}
+
+// Add adds a layer of variables.
+func (r *Resolver) Add(kind OriginKind, source string, vs VarSet) {
+	r.layers = append(r.layers, Layer{
+		Origin: Origin{Kind: kind, Source: source},
+		Vars:   vs,
+	})
+}
+
+// Resolve merges the layers and returns the variables and their origins.
+func (r *Resolver) Resolve() (VarSet, Origins) {
+	layers := make([]Layer, len(r.layers))
+	copy(layers, r.layers)
+	sort.SliceStable(layers, func(i, j int) bool {
+		return precedence(layers[i].Origin.Kind) < precedence(layers[j].Origin.Kind)
+	})
+
+	vs := VarSet{}
+	origins := Origins{}
+	for _, l := range layers {
+		for k, v := range l.Vars {
+			vs[k] = v
+			origins[k] = l.Origin
+		}
+	}
+
+	return vs, origins
+}
+
+// precedence returns the index of the kind in Precedence, kinds that are not user provided have the highest
+// precedence.
+func precedence(kind OriginKind) int {
+	for i, k := range Precedence {
+		if k == kind {
+			return i
+		}
+	}
+
+	return len(Precedence)
+}
@@ -0,0 +1,67 @@
+package vars
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolver(t *testing.T) {
+	var r Resolver
+
+	// layers are added out of order to check they are applied by precedence
+	r.Add(OriginFlag, "", VarSet{"node_count": 5.})
+	r.Add(OriginGlobal, "", VarSet{"node_count": 1., "region": "us-east-1", "token": "global"})
+	r.Add(OriginFile, "a.yaml", VarSet{"region": "us-west-1", "size": "small"})
+	r.Add(OriginFile, "b.yaml", VarSet{"region": "us-west-2"})
+	r.Add(OriginProfile, "aws", VarSet{"region": "eu-west-1", "size": "large"})
+	r.Add(OriginEnv, "", VarSet{"size": "medium"})
+	r.Add(OriginDefault, "", VarSet{"node_count": 3., "image": "ubuntu"})
+	r.Add(OriginLock, "k3s.lock.yaml", nil)
+
+	vs, origins := r.Resolve()
+
+	assert.Equal(t, VarSet{
+		"node_count": 5.,
+		"region":     "us-west-2",
+		"size":       "medium",
+		"token":      "global",
+		"image":      "ubuntu",
+	}, vs)
+	assert.Equal(t, Origins{
+		"node_count": {Kind: OriginFlag},
+		"region":     {Kind: OriginFile, Source: "b.yaml"},
+		"size":       {Kind: OriginEnv},
+		"token":      {Kind: OriginGlobal},
+		"image":      {Kind: OriginDefault},
+	}, origins)
+}
+
+func TestResolverLock(t *testing.T) {
+	var r Resolver
+	r.Add(OriginGlobal, "", VarSet{"a": "global", "b": "global"})
+	r.Add(OriginLock, "k3s.lock.yaml", VarSet{"a": "lock", "b": "lock"})
+	r.Add(OriginFlag, "", VarSet{"b": "flag"})
+
+	vs, origins := r.Resolve()
+
+	assert.Equal(t, VarSet{"a": "lock", "b": "flag"}, vs)
+	assert.Equal(t, Origin{Kind: OriginLock, Source: "k3s.lock.yaml"}, origins["a"])
+	assert.Equal(t, Origin{Kind: OriginFlag}, origins["b"])
+}
+
+func TestResolverRuntimeOrigins(t *testing.T) {
+	var r Resolver
+	r.Add(OriginTerraform, "main", VarSet{"a": "terraform"})
+	r.Add(OriginFlag, "", VarSet{"a": "flag"})
+
+	vs, origins := r.Resolve()
+
+	assert.Equal(t, VarSet{"a": "terraform"}, vs)
+	assert.Equal(t, "terraform (main)", origins["a"].String())
+}
+
+func TestOriginString(t *testing.T) {
+	assert.Equal(t, "flag", Origin{Kind: OriginFlag}.String())
+	assert.Equal(t, "node (server-0)", Origin{Kind: OriginNode, Source: "server-0"}.String())
+}